@@ -0,0 +1,270 @@
+package botc
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Frequency string
+
+const (
+	YouStartKnowing      Frequency = "You start knowing"
+	OnYourFirstNight     Frequency = "On your 1st night"
+	EachNight            Frequency = "Each night"
+	EachNightExceptFirst Frequency = "Each night*"
+	EachDay              Frequency = "Each day"
+	OncePerGame          Frequency = "Once per game"
+)
+
+// frequencyMarkers is searched in order, so a marker must come before any
+// other marker it starts with.
+var frequencyMarkers = []Frequency{
+	YouStartKnowing,
+	OnYourFirstNight,
+	EachNightExceptFirst,
+	EachNight,
+	EachDay,
+	OncePerGame,
+}
+
+func (f Frequency) String() string {
+	return string(f)
+}
+
+type SetupEffect string
+
+const (
+	AdjustTeam         SetupEffect = "team"
+	AddCharacter       SetupEffect = "character"
+	ReducePlayerCount  SetupEffect = "playerCount"
+	UnknownSetupEffect SetupEffect = "unknown"
+)
+
+// SetupModifier is one clause of a setup bracket. Options lists every
+// adjustment the Storyteller may choose between, so "+0 or +1 Outsider" has
+// two; Variable is set when the bracket leaves the amount open.
+type SetupModifier struct {
+	Effect    SetupEffect `json:"effect"`
+	Team      RoleType    `json:"team,omitempty"`
+	Character string      `json:"character,omitempty"`
+	Options   []int       `json:"options,omitempty"`
+	Variable  bool        `json:"variable,omitempty"`
+	Text      string      `json:"text"`
+}
+
+func (m SetupModifier) Might() bool {
+	return m.Variable || len(m.Options) > 1
+}
+
+type Ability struct {
+	Text        string          `json:"text"`
+	Setup       string          `json:"setup"`
+	Frequencies []Frequency     `json:"frequencies"`
+	Modifiers   []SetupModifier `json:"modifiers"`
+}
+
+func (a Ability) Has(f Frequency) bool {
+	return slices.Contains(a.Frequencies, f)
+}
+
+// AltersSetup reports whether any clause of the bracket changes the bag in a
+// way this package understands.
+func (a Ability) AltersSetup() bool {
+	return slices.ContainsFunc(a.Modifiers, func(m SetupModifier) bool {
+		return m.Effect != UnknownSetupEffect
+	})
+}
+
+// deadMarkers are the phrases that keep an ability working once its player
+// has died, or that only trigger on their death.
+var deadMarkers = []string{
+	"even if dead",
+	"if you die",
+	"when you die",
+	"if you died",
+	"you died",
+	"kills you",
+}
+
+// WorksWhenDead reports whether the ability still does something once its
+// player is dead.
+func (a Ability) WorksWhenDead() bool {
+	lower := strings.ToLower(a.Text)
+	return slices.ContainsFunc(deadMarkers, func(m string) bool {
+		return strings.Contains(lower, m)
+	})
+}
+
+func ParseAbility(text string) Ability {
+	a := Ability{
+		Text:        strings.TrimSpace(text),
+		Frequencies: []Frequency{},
+		Modifiers:   []SetupModifier{},
+	}
+	if brackets := setupBracket.FindAllStringSubmatch(text, -1); brackets != nil {
+		setups := make([]string, 0, len(brackets))
+		for _, b := range brackets {
+			setup := strings.TrimSpace(b[1])
+			if setup == "" {
+				continue
+			}
+			if n := len(setups); n > 0 && !strings.HasSuffix(setups[n-1], ".") {
+				setups[n-1] += "."
+			}
+			setups = append(setups, setup)
+			a.Modifiers = append(a.Modifiers, parseSetup(setup)...)
+		}
+		a.Text = strings.TrimSpace(setupBracket.ReplaceAllString(text, ""))
+		a.Setup = strings.Join(setups, " ")
+	} else if match := mightAdjustment.FindStringSubmatch(a.Text); match != nil {
+		// the Sentinel states its setup change in the ability itself
+		if team, ok := setupTeam(match[3]); ok {
+			m := SetupModifier{Effect: AdjustTeam, Team: team, Text: match[0]}
+			extra, extraOk := setupAmount(match[1])
+			fewer, fewerOk := setupAmount(match[2])
+			if extraOk && fewerOk {
+				m.Options = []int{-fewer, 0, extra}
+			} else {
+				m.Variable = true
+			}
+			a.Modifiers = append(a.Modifiers, m)
+		}
+	}
+	a.Frequencies = parseFrequencies(a.Text)
+	return a
+}
+
+func (r *Role) ParsedAbility() Ability {
+	return ParseAbility(r.Ability)
+}
+
+func parseFrequencies(text string) []Frequency {
+	found := []Frequency{}
+	lower := strings.ToLower(text)
+	for i := 0; i < len(lower); i++ {
+		if last, _ := utf8.DecodeLastRuneInString(lower[:i]); i > 0 && unicode.IsLetter(last) {
+			continue
+		}
+		for _, f := range frequencyMarkers {
+			marker := strings.ToLower(string(f))
+			if !strings.HasPrefix(lower[i:], marker) {
+				continue
+			}
+			if !slices.Contains(found, f) {
+				found = append(found, f)
+			}
+			i += len(marker) - 1
+			break
+		}
+	}
+	return found
+}
+
+var (
+	setupBracket    = regexp.MustCompile(`\[([^\[\]]*)\]`)
+	teamAdjustment  = regexp.MustCompile(`^([+-]?(?:\d+|\?)|X)(?:\s+(or|to)\s+([+-]?(?:\d+|\?)))?\s+(.+)$`)
+	addsCharacter   = regexp.MustCompile(`^\+the\s+(.+)$`)
+	mightAdjustment = regexp.MustCompile(`There might be (\d+) extra or (\d+) fewer (\w+) in play`)
+)
+
+func parseSetup(setup string) []SetupModifier {
+	mods := []SetupModifier{}
+	for _, clause := range strings.Split(setup, ".") {
+		clause = strings.TrimSpace(clause)
+		if clause != "" {
+			mods = append(mods, parseSetupClause(clause))
+		}
+	}
+	return mods
+}
+
+func parseSetupClause(clause string) SetupModifier {
+	m := SetupModifier{Effect: UnknownSetupEffect, Text: clause}
+	if strings.EqualFold(clause, "Reduce non-traveller player count") {
+		m.Effect = ReducePlayerCount
+		m.Options = []int{-1}
+		return m
+	}
+	if match := addsCharacter.FindStringSubmatch(clause); match != nil {
+		m.Effect = AddCharacter
+		m.Character = CanonicalId(match[1])
+		m.Options = []int{1}
+		return m
+	}
+	match := teamAdjustment.FindStringSubmatch(clause)
+	// a bare count such as "1 Townsfolk is evil" describes, it does not adjust
+	if match == nil || !(match[1] == "X" || strings.HasPrefix(match[1], "+") || strings.HasPrefix(match[1], "-")) {
+		return m
+	}
+	options, variable := setupOptions(match[1], match[2], match[3])
+	subject := match[4]
+	if team, ok := setupTeam(subject); ok {
+		m.Effect = AdjustTeam
+		m.Team = team
+	} else if unicode.IsUpper(rune(subject[0])) {
+		m.Effect = AddCharacter
+		if slices.Max(append([]int{0}, options...)) > 1 {
+			subject = strings.TrimSuffix(subject, "s")
+		}
+		m.Character = CanonicalId(subject)
+	} else {
+		return m
+	}
+	m.Options = options
+	m.Variable = variable
+	return m
+}
+
+// setupOptions expands "+1", "-1 or +1" and "+0 to +2" into the amounts the
+// Storyteller may pick from; "?" and "X" leave the amount open.
+func setupOptions(first, join, second string) ([]int, bool) {
+	low, lowOk := setupAmount(first)
+	if join == "" {
+		if !lowOk {
+			return nil, true
+		}
+		return []int{low}, false
+	}
+	high, highOk := setupAmount(second)
+	if !lowOk || !highOk {
+		return nil, true
+	}
+	if low > high {
+		low, high = high, low
+	}
+	if join == "or" {
+		return []int{low, high}, false
+	}
+	options := make([]int, 0, high-low+1)
+	for n := low; n <= high; n++ {
+		options = append(options, n)
+	}
+	return options, false
+}
+
+func setupTeam(subject string) (RoleType, bool) {
+	word := strings.ToLower(subject)
+	for _, rt := range []RoleType{Townsfolk, Outsider, Minion, Demon} {
+		if word == string(rt) || word == string(rt)+"s" {
+			return rt, true
+		}
+	}
+	return RoleType(""), false
+}
+
+// maxSetupAmount bounds the adjustments a bracket may ask for; nothing can
+// move more characters than a game has players, so anything larger is
+// treated as an amount left open.
+const maxSetupAmount = MaxPlayers
+
+func setupAmount(s string) (int, bool) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < -maxSetupAmount || n > maxSetupAmount {
+		return 0, false
+	}
+	return n, true
+}
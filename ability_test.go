@@ -0,0 +1,95 @@
+package botc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseAbilitySetup(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		setup string
+		want  []SetupModifier
+	}{
+		{
+			name:  "single",
+			text:  "Each night*, choose a player: they die. [+1 Outsider]",
+			setup: "+1 Outsider",
+			want:  []SetupModifier{{Effect: AdjustTeam, Team: Outsider, Options: []int{1}}},
+		},
+		{
+			name:  "range",
+			text:  "You start knowing something. [+0 to +2 Outsiders]",
+			setup: "+0 to +2 Outsiders",
+			want:  []SetupModifier{{Effect: AdjustTeam, Team: Outsider, Options: []int{0, 1, 2}}},
+		},
+		{
+			name:  "huge range",
+			text:  "Nothing. [+0 to +100000000000 Outsiders]",
+			setup: "+0 to +100000000000 Outsiders",
+			want:  []SetupModifier{{Effect: AdjustTeam, Team: Outsider, Variable: true}},
+		},
+		{
+			name:  "range beyond players",
+			text:  "Nothing. [+0 to +9223372036854775807 Outsiders]",
+			setup: "+0 to +9223372036854775807 Outsiders",
+			want:  []SetupModifier{{Effect: AdjustTeam, Team: Outsider, Variable: true}},
+		},
+		{
+			name:  "several brackets",
+			text:  "Something happens. [+1 Outsider] and [+1 Minion]",
+			setup: "+1 Outsider. +1 Minion",
+			want: []SetupModifier{
+				{Effect: AdjustTeam, Team: Outsider, Options: []int{1}},
+				{Effect: AdjustTeam, Team: Minion, Options: []int{1}},
+			},
+		},
+		{
+			name: "sentinel",
+			text: "There might be 1 extra or 1 fewer Outsider in play.",
+			want: []SetupModifier{{Effect: AdjustTeam, Team: Outsider, Options: []int{-1, 0, 1}}},
+		},
+		{
+			name: "unclosed",
+			text: "Each day, something. [+1 Outsider",
+			want: []SetupModifier{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := ParseAbility(tt.text)
+			if a.Setup != tt.setup {
+				t.Errorf("setup %q, want %q", a.Setup, tt.setup)
+			}
+			if len(a.Modifiers) != len(tt.want) {
+				t.Fatalf("modifiers %+v, want %+v", a.Modifiers, tt.want)
+			}
+			for i, w := range tt.want {
+				m := a.Modifiers[i]
+				if m.Effect != w.Effect || m.Team != w.Team || m.Variable != w.Variable || !slices.Equal(m.Options, w.Options) {
+					t.Errorf("modifier %d is %+v, want %+v", i, m, w)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAbilityFrequencies(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Frequency
+	}{
+		{"Each night, choose a player.", []Frequency{EachNight}},
+		{"Once per game, at night, choose a player.", []Frequency{OncePerGame}},
+		{"Peach night is not a marker.", []Frequency{}},
+		{"Éeach night is not a marker either.", []Frequency{}},
+		{"Ñonce per game neither.", []Frequency{}},
+		{"Déjà vu. Each day, you may visit.", []Frequency{EachDay}},
+	}
+	for _, tt := range tests {
+		if got := ParseAbility(tt.text).Frequencies; !slices.Equal(got, tt.want) {
+			t.Errorf("%q has frequencies %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package botc
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+const (
+	BagDisabled  = "bag-disabled"
+	BagDuplicate = "bag-duplicate"
+)
+
+const maxDealAttempts = 100
+
+var bagTeams = []RoleType{Demon, Minion, Outsider, Townsfolk}
+
+type BagOptions struct {
+	Seed     uint64   `json:"seed"`
+	Pinned   []string `json:"pinned"`
+	Excluded []string `json:"excluded"`
+}
+
+type Bag struct {
+	Seed         uint64       `json:"seed"`
+	Players      int          `json:"players"`
+	Distribution Distribution `json:"distribution"`
+	// Characters are the characters in play, including those that never go
+	// in the bag.
+	Characters []string `json:"characters"`
+	// Tokens are what actually goes in the bag.
+	Tokens []string `json:"tokens"`
+	// Substitutes maps each bag-disabled character in play to the token
+	// that stands in for it, such as the Townsfolk the Drunk thinks they are.
+	Substitutes map[string]string `json:"substitutes"`
+}
+
+// DealBag picks a legal set of characters from a resolved script for a game
+// of players non-traveller players. The same script, player count and
+// options always deal the same bag.
+func (s *Script) DealBag(players int, opts BagOptions) (Bag, error) {
+	bag := Bag{
+		Seed:        opts.Seed,
+		Players:     players,
+		Characters:  []string{},
+		Tokens:      []string{},
+		Substitutes: make(map[string]string),
+	}
+	if _, err := BaseDistribution(players); err != nil {
+		return bag, err
+	}
+
+	ids := make([]string, 0, len(s.Index))
+	for id, r := range s.Index {
+		if r != nil {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	pinned := make([]*Role, 0, len(opts.Pinned))
+	for _, id := range opts.Pinned {
+		r, found := s.Index[CanonicalId(id)]
+		if !found || r == nil {
+			return bag, NewIllegalValueForEnumError("pinned", id, ids)
+		}
+		pinned = append(pinned, r)
+	}
+	excluded := make([]string, len(opts.Excluded))
+	for i, id := range opts.Excluded {
+		excluded[i] = CanonicalId(id)
+	}
+
+	candidates := make(map[RoleType][]*Role)
+	for _, id := range ids {
+		r := s.Index[id]
+		if !slices.Contains(excluded, id) {
+			candidates[r.Team] = append(candidates[r.Team], r)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, 0))
+	for range maxDealAttempts {
+		selected, target, ok := s.deal(rng, players, slices.Clone(pinned), candidates, excluded)
+		if !ok {
+			continue
+		}
+		substitutes, ok := dealSubstitutes(rng, selected, candidates[Townsfolk])
+		if !ok {
+			continue
+		}
+		slices.SortStableFunc(selected, func(a, b *Role) int {
+			if a.Team != b.Team {
+				return slices.Index(RoleTypeOrder, a.Team) - slices.Index(RoleTypeOrder, b.Team)
+			}
+			return strings.Compare(a.Id, b.Id)
+		})
+		bag.Distribution = target
+		bag.Substitutes = substitutes
+		for _, r := range selected {
+			bag.Characters = append(bag.Characters, r.Id)
+			if !r.takesToken() {
+				continue
+			}
+			if sub, found := substitutes[r.Id]; found {
+				bag.Tokens = append(bag.Tokens, sub)
+			} else {
+				bag.Tokens = append(bag.Tokens, r.Id)
+			}
+		}
+		return bag, nil
+	}
+	return bag, NewBagError(players, opts.Seed)
+}
+
+// deal makes one attempt at filling the bag, drawing evil characters first
+// since they are the ones most likely to change the distribution. Whenever a
+// draw changes the legal distributions a new target is picked from them. An
+// attempt that needs an excluded character fails so another can be made.
+func (s *Script) deal(rng *rand.Rand, players int, selected []*Role, candidates map[RoleType][]*Role, excluded []string) ([]*Role, Distribution, bool) {
+	var target Distribution
+	targeted := false
+	for range players * len(bagTeams) {
+		plan, err := CalculateSetup(players, 0, selected)
+		if err != nil {
+			return nil, target, false
+		}
+		required := false
+		for _, id := range plan.Required {
+			if containsRole(selected, id) {
+				continue
+			}
+			if slices.Contains(excluded, id) {
+				return nil, target, false
+			}
+			r, found := s.Index[id]
+			if !found || r == nil {
+				official := OfficialRoster()
+				if r, found = official.Lookup(id); !found {
+					return nil, target, false
+				}
+			}
+			selected = append(selected, r)
+			required = true
+		}
+		if required {
+			continue
+		}
+		if !targeted || !slices.Contains(plan.Adjusted, target) {
+			target = plan.Adjusted[rng.IntN(len(plan.Adjusted))]
+			targeted = true
+		}
+
+		var short RoleType
+		for _, team := range bagTeams {
+			have := countTeam(selected, team)
+			if have > target.Count(team) {
+				return nil, target, false
+			}
+			if have < target.Count(team) && short == "" {
+				short = team
+			}
+		}
+		if short == "" {
+			return selected, target, true
+		}
+		pool := slices.DeleteFunc(slices.Clone(candidates[short]), func(r *Role) bool {
+			return !canDraw(r, selected)
+		})
+		if len(pool) == 0 {
+			return nil, target, false
+		}
+		selected = append(selected, pool[rng.IntN(len(pool))])
+	}
+	return nil, target, false
+}
+
+// canDraw reports whether r may go in the bag again. Characters are unique
+// unless they are bag-duplicate, in which case their own setup bracket may
+// limit how many copies there are.
+func canDraw(r *Role, selected []*Role) bool {
+	copies := 0
+	for _, o := range selected {
+		if o.Id == r.Id {
+			copies++
+		}
+	}
+	if copies == 0 {
+		return true
+	}
+	if !r.HasSpecial(BagDuplicate) {
+		return false
+	}
+	for _, m := range r.ParsedAbility().Modifiers {
+		if m.Effect == AddCharacter && m.Character == r.Id && !m.Variable {
+			return copies <= slices.Max(m.Options)
+		}
+	}
+	return true
+}
+
+// dealSubstitutes picks a Townsfolk that is not in play for every
+// bag-disabled character, so the bag still holds one token per player.
+func dealSubstitutes(rng *rand.Rand, selected []*Role, townsfolk []*Role) (map[string]string, bool) {
+	substitutes := make(map[string]string)
+	pool := slices.DeleteFunc(slices.Clone(townsfolk), func(r *Role) bool {
+		return containsRole(selected, r.Id)
+	})
+	for _, r := range selected {
+		if !r.HasSpecial(BagDisabled) || !r.takesToken() {
+			continue
+		}
+		if _, found := substitutes[r.Id]; found {
+			continue
+		}
+		if len(pool) == 0 {
+			return nil, false
+		}
+		i := rng.IntN(len(pool))
+		substitutes[r.Id] = pool[i].Id
+		pool = slices.Delete(pool, i, i+1)
+	}
+	return substitutes, true
+}
+
+// holdsSeat reports whether the character takes up a place in the
+// distribution. A bag-disabled character that changes the team counts, like
+// Lil' Monsta, gives its place up to the characters it adds, and one that
+// reduces the player count sits outside the distribution it reduced.
+func (r *Role) holdsSeat() bool {
+	modifiers := r.ParsedAbility().Modifiers
+	if slices.ContainsFunc(modifiers, func(m SetupModifier) bool {
+		return m.Effect == ReducePlayerCount
+	}) {
+		return false
+	}
+	if !r.HasSpecial(BagDisabled) {
+		return true
+	}
+	return !slices.ContainsFunc(modifiers, func(m SetupModifier) bool {
+		return m.Effect == AdjustTeam
+	})
+}
+
+// takesToken reports whether a player is dealt the character, or a token
+// standing in for it: everything that holds a seat, and the characters that
+// reduce the player count since their player still needs one.
+func (r *Role) takesToken() bool {
+	if !slices.Contains(bagTeams, r.Team) {
+		return false
+	}
+	return r.holdsSeat() || slices.ContainsFunc(r.ParsedAbility().Modifiers, func(m SetupModifier) bool {
+		return m.Effect == ReducePlayerCount
+	})
+}
+
+func countTeam(roles []*Role, team RoleType) int {
+	n := 0
+	for _, r := range roles {
+		if r.Team == team && r.holdsSeat() {
+			n++
+		}
+	}
+	return n
+}
+
+func containsRole(roles []*Role, id string) bool {
+	return slices.ContainsFunc(roles, func(r *Role) bool {
+		return r.Id == id
+	})
+}
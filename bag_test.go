@@ -0,0 +1,178 @@
+package botc
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+func loadScript(tb testing.TB, path string) *Script {
+	tb.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var s Script
+	if err := s.UnmarshalJSON(data); err != nil {
+		tb.Fatal(err)
+	}
+	if missing := s.PopulateOfficialIndex(); len(missing) > 0 {
+		tb.Fatalf("missing %v", missing)
+	}
+	return &s
+}
+
+func TestDealBagTokenPerPlayer(t *testing.T) {
+	for _, path := range []string{"asset/Stowed Away.json", "asset/Sects & Violets.json"} {
+		s := loadScript(t, path)
+		for _, players := range []int{MinPlayers, 7, 10, MaxPlayers} {
+			for seed := range uint64(10) {
+				bag, err := s.DealBag(players, BagOptions{Seed: seed})
+				if err != nil {
+					var bagErr *BagError
+					if errors.As(err, &bagErr) {
+						continue
+					}
+					t.Fatal(err)
+				}
+				if len(bag.Tokens) != players {
+					t.Fatalf("%s: %d players, seed %d: %d tokens %v", path, players, seed, len(bag.Tokens), bag.Tokens)
+				}
+			}
+		}
+	}
+}
+
+func TestDealBagRequiredExcluded(t *testing.T) {
+	s, err := DecodeScript([]byte(`["huntsman","damsel","washerwoman","librarian","investigator","chef","empath","fortuneteller","butler","recluse","saint","poisoner","spy","imp"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.PopulateOfficialIndex()
+	for seed := range uint64(50) {
+		bag, err := s.DealBag(7, BagOptions{Seed: seed, Excluded: []string{"damsel"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Contains(bag.Characters, "damsel") {
+			t.Fatalf("seed %d dealt the excluded damsel: %v", seed, bag.Characters)
+		}
+	}
+	_, err = s.DealBag(7, BagOptions{Pinned: []string{"huntsman"}, Excluded: []string{"damsel"}})
+	var bagErr *BagError
+	if !errors.As(err, &bagErr) {
+		t.Errorf("got %v, want a bag error", err)
+	}
+}
+
+const plainCharacters = `"washerwoman","librarian","investigator","chef","empath","fortuneteller","undertaker","monk","ravenkeeper","slayer","soldier","mayor","virgin",` +
+	`"butler","recluse","saint","mutant","klutz","poisoner","spy","scarletwoman","imp"`
+
+func TestDealBagModifiers(t *testing.T) {
+	tests := []struct {
+		players int
+		pinned  []string
+		want    []Distribution
+		// required is dealt as well as the pinned characters
+		required string
+	}{
+		{8, []string{"baron"}, []Distribution{{3, 3, 1, 1}}, ""},
+		{8, []string{"fanggu"}, []Distribution{{4, 2, 1, 1}}, ""},
+		{8, []string{"vigormortis"}, []Distribution{{6, 0, 1, 1}}, ""},
+		{8, []string{"lilmonsta"}, []Distribution{{5, 1, 2, 0}}, ""},
+		{8, []string{"huntsman"}, []Distribution{{4, 2, 1, 1}}, "damsel"},
+		{8, []string{"godfather"}, []Distribution{{6, 0, 1, 1}, {4, 2, 1, 1}}, ""},
+		{8, []string{"balloonist"}, []Distribution{{5, 1, 1, 1}, {4, 2, 1, 1}}, ""},
+		// the Hermit is an Outsider itself, so -1 never leaves none in play
+		{9, []string{"hermit"}, []Distribution{{6, 1, 1, 1}, {5, 2, 1, 1}}, ""},
+		{8, []string{"baron", "fanggu"}, []Distribution{{2, 4, 1, 1}}, ""},
+		{8, []string{"baron", "vigormortis"}, []Distribution{{4, 2, 1, 1}}, ""},
+		{8, []string{"baron", "lilmonsta"}, []Distribution{{3, 3, 2, 0}}, ""},
+		{8, []string{"huntsman", "baron"}, []Distribution{{2, 4, 1, 1}}, "damsel"},
+	}
+	for _, tt := range tests {
+		doc := "[" + plainCharacters
+		for _, id := range tt.pinned {
+			doc += `,"` + id + `"`
+		}
+		if tt.required != "" {
+			doc += `,"` + tt.required + `"`
+		}
+		s, err := DecodeScript([]byte(doc + "]"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if missing := s.PopulateOfficialIndex(); len(missing) > 0 {
+			t.Fatalf("missing %v", missing)
+		}
+		seen := make([]Distribution, 0)
+		for seed := range uint64(20) {
+			bag, err := s.DealBag(tt.players, BagOptions{Seed: seed, Pinned: tt.pinned})
+			if err != nil {
+				t.Fatalf("%v seed %d: %v", tt.pinned, seed, err)
+			}
+			if !slices.Contains(tt.want, bag.Distribution) {
+				t.Fatalf("%v seed %d: distribution %v, want one of %v", tt.pinned, seed, bag.Distribution, tt.want)
+			}
+			if !slices.Contains(seen, bag.Distribution) {
+				seen = append(seen, bag.Distribution)
+			}
+			for _, id := range tt.pinned {
+				if !slices.Contains(bag.Characters, id) {
+					t.Fatalf("%v seed %d: pinned %s not dealt: %v", tt.pinned, seed, id, bag.Characters)
+				}
+			}
+			if tt.required != "" && !slices.Contains(bag.Characters, tt.required) {
+				t.Fatalf("%v seed %d: %s not dealt: %v", tt.pinned, seed, tt.required, bag.Characters)
+			}
+			if len(bag.Tokens) != tt.players {
+				t.Fatalf("%v seed %d: %d tokens %v", tt.pinned, seed, len(bag.Tokens), bag.Tokens)
+			}
+		}
+		if len(seen) != len(tt.want) {
+			t.Errorf("%v: only dealt %v in 20 seeds, want each of %v", tt.pinned, seen, tt.want)
+		}
+	}
+}
+
+// TestDealBagMatchesSetup deals from scripts full of setup modifiers and
+// checks every bag against the setup its own characters call for.
+func TestDealBagMatchesSetup(t *testing.T) {
+	doc := "[" + plainCharacters + `,"balloonist","huntsman","damsel","hermit","baron","godfather","xaan","fanggu","vigormortis","lilmonsta","kazali"]`
+	s, err := DecodeScript([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := s.PopulateOfficialIndex(); len(missing) > 0 {
+		t.Fatalf("missing %v", missing)
+	}
+	for players := MinPlayers; players <= MaxPlayers; players++ {
+		for seed := range uint64(20) {
+			bag, err := s.DealBag(players, BagOptions{Seed: seed})
+			if err != nil {
+				var bagErr *BagError
+				if errors.As(err, &bagErr) {
+					continue
+				}
+				t.Fatal(err)
+			}
+			roles := make([]*Role, len(bag.Characters))
+			for i, id := range bag.Characters {
+				roles[i] = s.Index[id]
+			}
+			plan, err := CalculateSetup(players, 0, roles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Variable) == 0 && !slices.Contains(plan.Adjusted, bag.Distribution) {
+				t.Errorf("%d players, seed %d: %v dealt %v, setup allows %v", players, seed, bag.Characters, bag.Distribution, plan.Adjusted)
+			}
+			for _, team := range bagTeams {
+				if n := countTeam(roles, team); n != bag.Distribution.Count(team) {
+					t.Errorf("%d players, seed %d: %d %s in %v, distribution %v", players, seed, n, team, bag.Characters, bag.Distribution)
+				}
+			}
+		}
+	}
+}
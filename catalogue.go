@@ -0,0 +1,71 @@
+package botc
+
+import (
+	_ "embed"
+	"encoding/json"
+	"maps"
+	"sync"
+)
+
+//go:embed asset/official.json
+var officialData []byte
+
+var (
+	officialOnce   sync.Once
+	officialRoster Roster
+)
+
+// OfficialRoster returns the bundled catalogue of every official character,
+// including travellers, fabled and loric. The roster is decoded once and
+// shared, so callers should not modify the roles it holds.
+func OfficialRoster() Roster {
+	officialOnce.Do(func() {
+		err := json.Unmarshal(officialData, &officialRoster)
+		if err != nil {
+			panic("botc: invalid bundled official roster: " + err.Error())
+		}
+	})
+	return officialRoster
+}
+
+//go:embed asset/jinxes.json
+var officialJinxData []byte
+
+var (
+	officialJinxOnce sync.Once
+	officialJinxes   map[JinxKey]string
+)
+
+type jinxEntry struct {
+	Characters [2]string `json:"characters"`
+	Reason     string    `json:"reason"`
+}
+
+func loadOfficialJinxes() map[JinxKey]string {
+	officialJinxOnce.Do(func() {
+		var entries []jinxEntry
+		err := json.Unmarshal(officialJinxData, &entries)
+		if err != nil {
+			panic("botc: invalid bundled jinx table: " + err.Error())
+		}
+		officialJinxes = make(map[JinxKey]string, len(entries))
+		for _, e := range entries {
+			officialJinxes[NewJinxKey(e.Characters[0], e.Characters[1])] = e.Reason
+		}
+	})
+	return officialJinxes
+}
+
+// OfficialJinxes returns a copy of the bundled table of official jinxes.
+func OfficialJinxes() map[JinxKey]string {
+	return maps.Clone(loadOfficialJinxes())
+}
+
+func OfficialJinx(a string, b string) (string, bool) {
+	reason, found := loadOfficialJinxes()[NewJinxKey(a, b)]
+	return reason, found
+}
+
+func (s *Script) PopulateOfficialIndex() []string {
+	return s.PopulateIndexFrom(DefaultResolver()).Missing
+}
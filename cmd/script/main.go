@@ -21,24 +21,44 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to unmarshal json: %s", err)
 	}
-	rosterPath := os.Args[2]
-	rosterData, err := os.ReadFile(rosterPath)
-	if err != nil {
-		log.Fatalf("failed to read %s: %s", rosterPath, err)
-	}
-	var r botc.Roster
-	err = json.Unmarshal(rosterData, &r)
-	if err != nil {
-		log.Fatalf("failed to unmarshal json: %s", err)
+	resolver := botc.NewResolver()
+	for _, rosterPath := range os.Args[2:] {
+		rosterData, err := os.ReadFile(rosterPath)
+		if err != nil {
+			log.Fatalf("failed to read %s: %s", rosterPath, err)
+		}
+		var r botc.Roster
+		err = json.Unmarshal(rosterData, &r)
+		if err != nil {
+			log.Fatalf("failed to unmarshal json: %s", err)
+		}
+		resolver.Add(rosterPath, &r)
 	}
+	official := botc.OfficialRoster()
+	resolver.Add("official", &official)
 
 	fmt.Printf("Script: %s by %s\n", s.Meta.Name, s.Author())
 	if s.Meta.Almanac != "" {
 		fmt.Printf("Learn more at %s\n", s.Meta.Almanac)
 	}
-	missing := s.PopulateIndex(r)
-	if len(missing) > 0 {
-		fmt.Printf("Missing originals: %s", strings.Join(missing, ", "))
+	res := s.PopulateIndexFrom(resolver)
+	if len(res.Missing) > 0 {
+		fmt.Printf("Missing originals: %s\n", strings.Join(res.Missing, ", "))
+	}
+	for _, id := range res.Missing {
+		if suggestions, found := res.Suggestions[id]; found {
+			fmt.Printf("Did you mean %s instead of %s?\n", strings.Join(suggestions, " or "), id)
+		}
+	}
+	for _, sh := range res.Shadowed {
+		fmt.Printf("%s from %s shadows %s\n", sh.Id, sh.Source, strings.Join(sh.Shadowed, ", "))
+	}
+	unknownFirst, unknownOther := s.UnknownNightOrderIds()
+	if len(unknownFirst) > 0 {
+		fmt.Printf("Unknown in first night order: %s\n", strings.Join(unknownFirst, ", "))
+	}
+	if len(unknownOther) > 0 {
+		fmt.Printf("Unknown in other night order: %s\n", strings.Join(unknownOther, ", "))
 	}
 	fmt.Println("First Night Order:")
 	for i, j := range s.FirstNight() {
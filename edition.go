@@ -30,7 +30,11 @@ var EditionOrder = []Edition{
 }
 
 func (e Edition) Name() string {
-	return EditionName[e]
+	name, found := EditionName[e]
+	if !found {
+		return string(e)
+	}
+	return name
 }
 
 func (e Edition) Id() string {
@@ -0,0 +1,229 @@
+package botc
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// objectEncoder writes a JSON object with its keys in the order they are
+// added, which encoding a map cannot do. Unlike json.Marshal it leaves &, <
+// and > alone, so text reads back the way it was written.
+type objectEncoder struct {
+	buf bytes.Buffer
+	n   int
+	err error
+}
+
+func newObjectEncoder() *objectEncoder {
+	e := &objectEncoder{}
+	e.buf.WriteByte('{')
+	return e
+}
+
+type member struct {
+	key   string
+	value any
+}
+
+// fields writes the members each emits, putting those named in order first
+// and in that order, so an object decoded from a document keeps its layout.
+// The rest follow in the order they were emitted.
+func (e *objectEncoder) fields(order []string, each func(emit func(k string, v any))) {
+	if len(order) == 0 {
+		each(e.field)
+		return
+	}
+	members := make([]member, 0, len(order))
+	each(func(k string, v any) {
+		members = append(members, member{key: k, value: v})
+	})
+	done := make([]bool, len(members))
+	for _, k := range order {
+		i := slices.IndexFunc(members, func(m member) bool { return m.key == k })
+		if i != -1 && !done[i] {
+			e.field(k, members[i].value)
+			done[i] = true
+		}
+	}
+	for i, m := range members {
+		if !done[i] {
+			e.field(m.key, m.value)
+		}
+	}
+}
+
+// object writes a nested object.
+func (e *objectEncoder) object(order []string, each func(emit func(k string, v any))) {
+	sub := newObjectEncoder()
+	sub.fields(order, each)
+	data, err := sub.bytes()
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.buf.Write(data)
+}
+
+func (e *objectEncoder) field(k string, v any) {
+	if e.err != nil {
+		return
+	}
+	if e.n > 0 {
+		e.buf.WriteByte(',')
+	}
+	e.value(k)
+	e.buf.WriteByte(':')
+	e.value(v)
+	e.n++
+}
+
+// value writes the common field types directly, producing exactly what
+// marshalValue would, and hands everything else to marshalValue.
+func (e *objectEncoder) value(v any) {
+	switch x := v.(type) {
+	case string:
+		if plainString(x) {
+			e.buf.WriteByte('"')
+			e.buf.WriteString(x)
+			e.buf.WriteByte('"')
+			return
+		}
+	case RoleType:
+		e.value(string(x))
+		return
+	case Edition:
+		e.value(string(x))
+		return
+	case bool:
+		e.buf.WriteString(strconv.FormatBool(x))
+		return
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1e15 && !(x == 0 && math.Signbit(x)) {
+			e.buf.Write(strconv.AppendInt(e.buf.AvailableBuffer(), int64(x), 10))
+			return
+		}
+	case []string:
+		if x != nil {
+			e.buf.WriteByte('[')
+			for i, s := range x {
+				if i > 0 {
+					e.buf.WriteByte(',')
+				}
+				e.value(s)
+			}
+			e.buf.WriteByte(']')
+			return
+		}
+	case Jinxes:
+		if x != nil {
+			e.buf.WriteByte('[')
+			for i, j := range x {
+				if i > 0 {
+					e.buf.WriteByte(',')
+				}
+				e.object(nil, j.encodeFields)
+			}
+			e.buf.WriteByte(']')
+			return
+		}
+	case []Special:
+		if x != nil {
+			e.buf.WriteByte('[')
+			for i, s := range x {
+				if i > 0 {
+					e.buf.WriteByte(',')
+				}
+				e.object(s.order, s.encodeAll)
+			}
+			e.buf.WriteByte(']')
+			return
+		}
+	}
+	data, err := marshalValue(v)
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.buf.Write(data)
+}
+
+// marshalValue is json.Marshal without the HTML escaping.
+func marshalValue(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// plainString reports whether s needs no escaping.
+func plainString(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r < 0x20, r == '"', r == '\\', r == '\u2028', r == '\u2029':
+			return false
+		}
+	}
+	return true
+}
+
+func (e *objectEncoder) extra(extra map[string]any, known []string) {
+	for _, k := range extraKeys(extra, known) {
+		e.field(k, extra[k])
+	}
+}
+
+// extraKeys lists the keys of extra that are not known, sorted.
+func extraKeys(extra map[string]any, known []string) []string {
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		if !slices.Contains(known, k) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (e *objectEncoder) bytes() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.buf.WriteByte('}')
+	return e.buf.Bytes(), nil
+}
+
+// encodeArray writes items as a JSON array, calling each item's own
+// MarshalJSON directly rather than through json.Marshal, which would check
+// and compact every item's output again.
+func encodeArray(items []any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		var data []byte
+		var err error
+		if m, ok := item.(json.Marshaler); ok {
+			data, err = m.MarshalJSON()
+		} else {
+			data, err = marshalValue(item)
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package botc
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func loadRoster(tb testing.TB, path string) Roster {
+	tb.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var r Roster
+	if err := json.Unmarshal(data, &r); err != nil {
+		tb.Fatal(err)
+	}
+	return r
+}
+
+func TestMarshalByValue(t *testing.T) {
+	r := loadRoster(t, "asset/Released_Homebrew.json")
+	for _, c := range r.Characters {
+		want, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(*c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("%s by value:\n got %s\nwant %s", c.Id, got, want)
+		}
+		byId, err := json.Marshal(map[string]Role{c.Id: *c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(byId) != `{"`+c.Id+`":`+string(want)+`}` {
+			t.Fatalf("%s in a map: %s", c.Id, byId)
+		}
+		for _, s := range c.Special {
+			want, _ := json.Marshal(&s)
+			got, _ := json.Marshal(s)
+			if string(got) != string(want) {
+				t.Fatalf("%s special by value:\n got %s\nwant %s", c.Id, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkRosterMarshalJSON(b *testing.B) {
+	r := loadRoster(b, "asset/Released_Homebrew.json")
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := r.MarshalJSON(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRoleMarshalJSON(b *testing.B) {
+	r := loadRoster(b, "asset/Released_Homebrew.json")
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, c := range r.Characters {
+			if _, err := c.MarshalJSON(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkRoleToMap encodes through a map, the way roles were encoded
+// before they had their own MarshalJSON.
+func BenchmarkRoleToMap(b *testing.B) {
+	r := loadRoster(b, "asset/Released_Homebrew.json")
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, c := range r.Characters {
+			if _, err := json.Marshal(c.ToMap()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package botc
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type ConversionError[T any] struct {
 	key   string
@@ -49,3 +52,167 @@ func NewRequiredFieldMissingError(key string) *RequiredFieldMissingError {
 		key: key,
 	}
 }
+
+type DecodeError struct {
+	id   string
+	path string
+	err  error
+}
+
+func (e *DecodeError) Error() string {
+	if e.id != "" {
+		return fmt.Sprintf("%s (%s): %v", e.path, e.id, e.err)
+	}
+	return fmt.Sprintf("%s: %v", e.path, e.err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.err
+}
+
+func (e *DecodeError) Id() string {
+	return e.id
+}
+
+func (e *DecodeError) Path() string {
+	return e.path
+}
+
+func NewDecodeError(id string, path string, err error) *DecodeError {
+	return &DecodeError{
+		id:   id,
+		path: path,
+		err:  err,
+	}
+}
+
+type DecodeErrors struct {
+	errs []*DecodeError
+}
+
+func (e *DecodeErrors) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d decoding error(s): %s", len(e.errs), strings.Join(msgs, "; "))
+}
+
+func (e *DecodeErrors) Errors() []*DecodeError {
+	return e.errs
+}
+
+func (e *DecodeErrors) Unwrap() []error {
+	errs := make([]error, len(e.errs))
+	for i, err := range e.errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+func NewDecodeErrors(errs []*DecodeError) *DecodeErrors {
+	return &DecodeErrors{
+		errs: errs,
+	}
+}
+
+type PlayerCountError struct {
+	players    int
+	travellers int
+}
+
+func (e *PlayerCountError) Error() string {
+	return fmt.Sprintf("cannot set up a game for %d players and %d travellers, must be %d-%d players and at most %d travellers", e.players, e.travellers, MinPlayers, MaxPlayers, MaxTravellers)
+}
+
+func NewPlayerCountError(players int, travellers int) *PlayerCountError {
+	return &PlayerCountError{
+		players:    players,
+		travellers: travellers,
+	}
+}
+
+type BagError struct {
+	players int
+	seed    uint64
+}
+
+func (e *BagError) Error() string {
+	return fmt.Sprintf("could not deal a legal bag for %d players from seed %d", e.players, e.seed)
+}
+
+func NewBagError(players int, seed uint64) *BagError {
+	return &BagError{
+		players: players,
+		seed:    seed,
+	}
+}
+
+type SeatError struct {
+	seat  int
+	seats int
+}
+
+func (e *SeatError) Error() string {
+	return fmt.Sprintf("no seat %d, the grimoire has %d seats", e.seat, e.seats)
+}
+
+func NewSeatError(seat int, seats int) *SeatError {
+	return &SeatError{
+		seat:  seat,
+		seats: seats,
+	}
+}
+
+type UnknownCharacterError struct {
+	id string
+}
+
+func (e *UnknownCharacterError) Error() string {
+	return fmt.Sprintf("unknown character %s", e.id)
+}
+
+func NewUnknownCharacterError(id string) *UnknownCharacterError {
+	return &UnknownCharacterError{
+		id: id,
+	}
+}
+
+type IllegalEventError struct {
+	kind   EventKind
+	reason string
+}
+
+func (e *IllegalEventError) Error() string {
+	return fmt.Sprintf("illegal %s event: %s", e.kind, e.reason)
+}
+
+func NewIllegalEventError(kind EventKind, reason string) *IllegalEventError {
+	return &IllegalEventError{
+		kind:   kind,
+		reason: reason,
+	}
+}
+
+type ShareUrlError struct {
+	reason string
+	err    error
+}
+
+func (e *ShareUrlError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("invalid share url: %s: %v", e.reason, e.err)
+	}
+	return fmt.Sprintf("invalid share url: %s", e.reason)
+}
+
+func (e *ShareUrlError) Unwrap() error {
+	return e.err
+}
+
+func NewShareUrlError(reason string, err error) *ShareUrlError {
+	return &ShareUrlError{
+		reason: reason,
+		err:    err,
+	}
+}
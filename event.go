@@ -16,14 +16,14 @@ var eventNames = map[Event]string{
 	Dawn:       "Dawn",
 }
 
-var firstNight = map[Event]int{
+var firstNight = map[Event]float64{
 	Dusk:       1,
 	MinionInfo: 19,
 	DemonInfo:  23,
 	Dawn:       77,
 }
 
-var otherNights = map[Event]int{
+var otherNights = map[Event]float64{
 	Dusk: 1,
 	Dawn: 96,
 }
@@ -36,7 +36,7 @@ func (e Event) String() string {
 	return string(e)
 }
 
-func (e Event) GetFirstNightOrder() int {
+func (e Event) GetFirstNightOrder() float64 {
 	order, found := firstNight[e]
 	if !found {
 		return -1
@@ -44,7 +44,7 @@ func (e Event) GetFirstNightOrder() int {
 	return order
 }
 
-func (e Event) GetOtherNightsOrder() int {
+func (e Event) GetOtherNightsOrder() float64 {
 	order, found := otherNights[e]
 	if !found {
 		return -1
@@ -1,7 +1,12 @@
 package botc
 
+import "slices"
+
 func convertStringSlice(x any) ([]string, bool) {
-	xs := x.([]any)
+	xs, ok := x.([]any)
+	if !ok {
+		return []string{}, false
+	}
 	r := make([]string, len(xs))
 	for i, s := range xs {
 		S, ok := s.(string)
@@ -37,6 +42,14 @@ func extractString(k string, m map[string]any) (string, bool, error) {
 	return value, true, nil
 }
 
+func extractOptionalString(k string, m map[string]any) (string, error) {
+	if m[k] == nil {
+		return "", nil
+	}
+	value, _, err := extractString(k, m)
+	return value, err
+}
+
 func extractRequiredString(k string, m map[string]any) (string, error) {
 	value, ok, err := extractString(k, m)
 	if !ok {
@@ -63,30 +76,18 @@ func extractStringSlice(k string, m map[string]any) ([]string, bool, error) {
 	return values, true, nil
 }
 
-func extractInt(k string, m map[string]any) (int, bool, error) {
+func extractFloat(k string, m map[string]any) (float64, bool, error) {
 	v, ok := m[k]
 	if !ok {
 		return 0, false, nil
 	}
-	value64, ok := v.(float64)
+	value, ok := v.(float64)
 	if !ok {
 		return 0, true, NewConversionError(k, v)
 	}
-	value := int(value64)
 	return value, true, nil
 }
 
-func extractRoleRequiredInt(k string, m map[string]any) (int, error) {
-	value, ok, err := extractInt(k, m)
-	if !ok {
-		return 0, NewRequiredFieldMissingError(k)
-	}
-	if err != nil {
-		return 0, err
-	}
-	return value, nil
-}
-
 func extractBool(k string, m map[string]any) (bool, bool, error) {
 	v, ok := m[k]
 	if !ok {
@@ -109,3 +110,16 @@ func extractRequiredBool(k string, m map[string]any) (bool, error) {
 	}
 	return value, nil
 }
+
+func extractExtra(m map[string]any, known []string) map[string]any {
+	extra := make(map[string]any)
+	for k, v := range m {
+		if !slices.Contains(known, k) {
+			extra[k] = v
+		}
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	return extra
+}
@@ -0,0 +1,104 @@
+package botc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fuzzSeeds adds every document in asset/ to the corpus, along with the
+// malformed shapes that used to panic the decoder.
+func fuzzSeeds(f *testing.F) {
+	files, err := filepath.Glob("asset/*.json")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	for _, seed := range []string{
+		`[{"id":"_meta","author":1}]`,
+		`[{"id":"x","name":"X","team":"minion","ability":"y","jinxes":[1]}]`,
+		`[{"id":"x","name":"X","team":"minion","ability":"y","jinxes":[{"id":2}]}]`,
+		`[{"id":"x","name":"X","team":"minion","ability":"y","special":[{"type":1}]}]`,
+		`[{"id":"x","name":"X","team":"minion","ability":"y","reminders":[1]}]`,
+		`[{"id":"x","name":"X","team":"minion","ability":"no bracket ]"}]`,
+		`[{"id":"x","name":"X","team":"minion","ability":"[+0 to +99 Outsiders]"}]`,
+		`[null,1,true,[],"a",{}]`,
+	} {
+		f.Add([]byte(seed))
+	}
+}
+
+func exerciseRole(r *Role) {
+	r.AbilityText()
+	r.Setup()
+	r.ParsedAbility()
+	r.RichAbility(nil)
+	r.MarshalJSON()
+	r.ToMap()
+}
+
+func FuzzScript(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var strict Script
+		_ = strict.UnmarshalJSON(data)
+		s, _ := DecodeScript(data)
+		s.MarshalJSON()
+		s.MarshalCompactJSON()
+		s.Validate()
+		for i := range s.CustomCharacters {
+			exerciseRole(&s.CustomCharacters[i])
+		}
+		s.PopulateOfficialIndex()
+		roles := make([]*Role, 0, len(s.Index))
+		for _, r := range s.Index {
+			if r != nil {
+				roles = append(roles, r)
+			}
+		}
+		CalculateSetup(7, 0, roles)
+		s.DealBag(7, BagOptions{})
+		s.FirstNight()
+		s.OtherNights()
+		AnalyseJinxes(roles)
+	})
+}
+
+func FuzzRoster(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var strict Roster
+		_ = strict.UnmarshalJSON(data)
+		r, _ := DecodeRoster(data)
+		r.MarshalJSON()
+		for _, c := range r.Characters {
+			exerciseRole(c)
+		}
+	})
+}
+
+func FuzzRosterDecoder(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := NewRosterDecoder(bytes.NewReader(data))
+		for {
+			r, err := d.Next()
+			if err != nil {
+				var decodeErrs *DecodeErrors
+				if errors.As(err, &decodeErrs) {
+					continue
+				}
+				return
+			}
+			exerciseRole(r)
+		}
+	})
+}
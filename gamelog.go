@@ -0,0 +1,442 @@
+package botc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+type EventKind string
+
+const (
+	RoleAssigned     EventKind = "roleAssigned"
+	ReminderPlaced   EventKind = "reminderPlaced"
+	ReminderRemoved  EventKind = "reminderRemoved"
+	PlayerDied       EventKind = "death"
+	PlayerNominated  EventKind = "nomination"
+	VoteCast         EventKind = "vote"
+	PlayerExecuted   EventKind = "execution"
+	AlignmentChanged EventKind = "alignmentChanged"
+	PhaseChanged     EventKind = "phaseChanged"
+)
+
+// GameEvent is one thing that happened in a game. Events only ever change a
+// grimoire by being applied to it, so replaying a log rebuilds the game.
+type GameEvent interface {
+	Kind() EventKind
+	Apply(g *Grimoire) error
+}
+
+type RoleAssignedEvent struct {
+	Seat      int    `json:"seat"`
+	Character string `json:"character"`
+	Displayed string `json:"displayed,omitempty"`
+}
+
+func (e *RoleAssignedEvent) Kind() EventKind {
+	return RoleAssigned
+}
+
+func (e *RoleAssignedEvent) Apply(g *Grimoire) error {
+	actual, found := g.Source().Lookup(e.Character)
+	if !found {
+		return NewUnknownCharacterError(e.Character)
+	}
+	var displayed *Role
+	if e.Displayed != "" {
+		displayed, found = g.Source().Lookup(e.Displayed)
+		if !found {
+			return NewUnknownCharacterError(e.Displayed)
+		}
+	}
+	return g.AssignAs(e.Seat, actual, displayed)
+}
+
+type ReminderPlacedEvent struct {
+	Seat   int    `json:"seat"`
+	Source string `json:"source"`
+	Text   string `json:"text"`
+}
+
+func (e *ReminderPlacedEvent) Kind() EventKind {
+	return ReminderPlaced
+}
+
+func (e *ReminderPlacedEvent) Apply(g *Grimoire) error {
+	source, found := g.Source().Lookup(e.Source)
+	if !found {
+		return NewUnknownCharacterError(e.Source)
+	}
+	return g.PlaceReminder(e.Seat, source, e.Text)
+}
+
+type ReminderRemovedEvent struct {
+	Seat   int    `json:"seat"`
+	Source string `json:"source"`
+	Text   string `json:"text"`
+}
+
+func (e *ReminderRemovedEvent) Kind() EventKind {
+	return ReminderRemoved
+}
+
+func (e *ReminderRemovedEvent) Apply(g *Grimoire) error {
+	removed, err := g.RemoveReminder(e.Seat, e.Source, e.Text)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return NewIllegalEventError(e.Kind(), "no such reminder by seat "+strconv.Itoa(e.Seat))
+	}
+	return nil
+}
+
+type DeathEvent struct {
+	Seat int `json:"seat"`
+}
+
+func (e *DeathEvent) Kind() EventKind {
+	return PlayerDied
+}
+
+func (e *DeathEvent) Apply(g *Grimoire) error {
+	return g.Kill(e.Seat)
+}
+
+type NominationEvent struct {
+	Nominator int `json:"nominator"`
+	Nominee   int `json:"nominee"`
+}
+
+func (e *NominationEvent) Kind() EventKind {
+	return PlayerNominated
+}
+
+func (e *NominationEvent) Apply(g *Grimoire) error {
+	if g.Phase != DayPhase {
+		return NewIllegalEventError(e.Kind(), "nominations only happen during the day")
+	}
+	nominator, err := g.seat(e.Nominator)
+	if err != nil {
+		return err
+	}
+	if _, err := g.seat(e.Nominee); err != nil {
+		return err
+	}
+	if nominator.Dead {
+		return NewIllegalEventError(e.Kind(), "dead players cannot nominate")
+	}
+	g.Nominations = append(g.Nominations, Nomination{
+		Nominator: e.Nominator,
+		Nominee:   e.Nominee,
+		Votes:     []int{},
+	})
+	return nil
+}
+
+// VoteEvent is a vote on the most recent nomination. A dead player voting
+// spends their ghost vote.
+type VoteEvent struct {
+	Seat int `json:"seat"`
+}
+
+func (e *VoteEvent) Kind() EventKind {
+	return VoteCast
+}
+
+func (e *VoteEvent) Apply(g *Grimoire) error {
+	if len(g.Nominations) == 0 {
+		return NewIllegalEventError(e.Kind(), "nobody has been nominated")
+	}
+	s, err := g.seat(e.Seat)
+	if err != nil {
+		return err
+	}
+	n := &g.Nominations[len(g.Nominations)-1]
+	if slices.Contains(n.Votes, e.Seat) {
+		return NewIllegalEventError(e.Kind(), "seat "+strconv.Itoa(e.Seat)+" has already voted")
+	}
+	if s.Dead {
+		used, _ := g.UseGhostVote(e.Seat)
+		if !used {
+			return NewIllegalEventError(e.Kind(), "seat "+strconv.Itoa(e.Seat)+" has no vote left")
+		}
+	}
+	n.Votes = append(n.Votes, e.Seat)
+	return nil
+}
+
+type ExecutionEvent struct {
+	Seat int `json:"seat"`
+}
+
+func (e *ExecutionEvent) Kind() EventKind {
+	return PlayerExecuted
+}
+
+func (e *ExecutionEvent) Apply(g *Grimoire) error {
+	return g.Kill(e.Seat)
+}
+
+type AlignmentChangedEvent struct {
+	Seat      int       `json:"seat"`
+	Alignment Alignment `json:"alignment"`
+}
+
+func (e *AlignmentChangedEvent) Kind() EventKind {
+	return AlignmentChanged
+}
+
+func (e *AlignmentChangedEvent) Apply(g *Grimoire) error {
+	return g.SetAlignment(e.Seat, e.Alignment)
+}
+
+type PhaseChangedEvent struct {
+	Phase Phase `json:"phase"`
+}
+
+func (e *PhaseChangedEvent) Kind() EventKind {
+	return PhaseChanged
+}
+
+// Apply moves the game on. Nightfall starts a new day number and daybreak
+// clears the previous day's nominations.
+func (e *PhaseChangedEvent) Apply(g *Grimoire) error {
+	if e.Phase != NightPhase && e.Phase != DayPhase {
+		return NewIllegalValueForEnumError("phase", e.Phase, []Phase{NightPhase, DayPhase})
+	}
+	if e.Phase == g.Phase {
+		return NewIllegalEventError(e.Kind(), "the game is already in the "+string(e.Phase)+" phase")
+	}
+	if e.Phase == NightPhase || g.Day == 0 {
+		g.Day++
+	}
+	if e.Phase == DayPhase {
+		g.Nominations = []Nomination{}
+	}
+	g.Phase = e.Phase
+	return nil
+}
+
+var gameEvents = map[EventKind]func() GameEvent{
+	RoleAssigned:     func() GameEvent { return &RoleAssignedEvent{} },
+	ReminderPlaced:   func() GameEvent { return &ReminderPlacedEvent{} },
+	ReminderRemoved:  func() GameEvent { return &ReminderRemovedEvent{} },
+	PlayerDied:       func() GameEvent { return &DeathEvent{} },
+	PlayerNominated:  func() GameEvent { return &NominationEvent{} },
+	VoteCast:         func() GameEvent { return &VoteEvent{} },
+	PlayerExecuted:   func() GameEvent { return &ExecutionEvent{} },
+	AlignmentChanged: func() GameEvent { return &AlignmentChangedEvent{} },
+	PhaseChanged:     func() GameEvent { return &PhaseChangedEvent{} },
+}
+
+// GameLog is the append-only history of a game. Undo and Redo move a cursor
+// through the history; appending after an undo discards what was undone.
+type GameLog struct {
+	players []string
+	events  []GameEvent
+	cursor  int
+	state   *Grimoire
+	source  CharacterSource
+}
+
+func NewGameLog(source CharacterSource, players ...string) *GameLog {
+	return &GameLog{
+		players: players,
+		events:  make([]GameEvent, 0),
+		state:   NewGrimoire(source, players...),
+		source:  source,
+	}
+}
+
+// State is the game as of the cursor. It belongs to the log and should only
+// be changed through Append.
+func (l *GameLog) State() *Grimoire {
+	return l.state
+}
+
+// Events lists copies of the events up to the cursor, so changing them does
+// not rewrite the history.
+func (l *GameLog) Events() []GameEvent {
+	events := make([]GameEvent, l.cursor)
+	for i, e := range l.events[:l.cursor] {
+		events[i] = copyEvent(e)
+	}
+	return events
+}
+
+func (l *GameLog) Len() int {
+	return l.cursor
+}
+
+// Append applies a copy of e and records it, so the caller may go on to
+// reuse e without changing the history.
+func (l *GameLog) Append(e GameEvent) error {
+	e = copyEvent(e)
+	if err := e.Apply(l.state); err != nil {
+		// a failed event may have got part way, so start again
+		l.state, _ = l.Replay(l.cursor)
+		return err
+	}
+	l.events = append(l.events[:l.cursor], e)
+	l.cursor++
+	return nil
+}
+
+// copyEvent copies the value behind an event pointer. Events are flat
+// values, so a shallow copy is a whole one.
+func copyEvent(e GameEvent) GameEvent {
+	v := reflect.ValueOf(e)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return e
+	}
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	return c.Interface().(GameEvent)
+}
+
+func (l *GameLog) CanUndo() bool {
+	return l.cursor > 0
+}
+
+func (l *GameLog) CanRedo() bool {
+	return l.cursor < len(l.events)
+}
+
+func (l *GameLog) Undo() bool {
+	if !l.CanUndo() {
+		return false
+	}
+	state, err := l.Replay(l.cursor - 1)
+	if err != nil {
+		return false
+	}
+	l.cursor--
+	l.state = state
+	return true
+}
+
+func (l *GameLog) Redo() bool {
+	if !l.CanRedo() {
+		return false
+	}
+	if err := l.events[l.cursor].Apply(l.state); err != nil {
+		l.state, _ = l.Replay(l.cursor)
+		return false
+	}
+	l.cursor++
+	return true
+}
+
+// Replay rebuilds the game from scratch as it stood after the first n
+// events, including any that have been undone but not discarded. n is
+// clamped to the events there are.
+func (l *GameLog) Replay(n int) (*Grimoire, error) {
+	g := NewGrimoire(l.source, l.players...)
+	n = max(0, min(n, len(l.events)))
+	for i, e := range l.events[:n] {
+		if err := e.Apply(g); err != nil {
+			return g, NewDecodeError("", pointerJoin("", strconv.Itoa(i)), err)
+		}
+	}
+	return g, nil
+}
+
+type gameLogHeader struct {
+	Players []string `json:"players"`
+}
+
+// WriteJSONLines archives the log as a header line naming the players
+// followed by one line per event. Undone events are not written.
+func (l *GameLog) WriteJSONLines(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(gameLogHeader{Players: l.players}); err != nil {
+		return err
+	}
+	for _, e := range l.Events() {
+		line, err := encodeGameEvent(e)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeGameEvent writes the event's own fields after its kind, so each line
+// reads as one flat object.
+func encodeGameEvent(e GameEvent) ([]byte, error) {
+	kind, err := json.Marshal(e.Kind())
+	if err != nil {
+		return nil, err
+	}
+	fields, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`{"kind":`)
+	buf.Write(kind)
+	if len(fields) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.Write(fields[1:])
+	return buf.Bytes(), nil
+}
+
+// ReadGameLog loads a log written by WriteJSONLines, replaying every event
+// against characters from source.
+func ReadGameLog(r io.Reader, source CharacterSource) (*GameLog, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, NewRequiredFieldMissingError("players")
+	}
+	var header gameLogHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, NewDecodeError("", "/0", err)
+	}
+	l := NewGameLog(source, header.Players...)
+	for line := 1; scanner.Scan(); line++ {
+		ptr := pointerJoin("", strconv.Itoa(line))
+		raw := scanner.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		var kind struct {
+			Kind EventKind `json:"kind"`
+		}
+		if err := json.Unmarshal(raw, &kind); err != nil {
+			return nil, NewDecodeError("", ptr, err)
+		}
+		newEvent, found := gameEvents[kind.Kind]
+		if !found {
+			kinds := make([]EventKind, 0, len(gameEvents))
+			for k := range gameEvents {
+				kinds = append(kinds, k)
+			}
+			slices.Sort(kinds)
+			return nil, NewDecodeError("", pointerJoin(ptr, "kind"), NewIllegalValueForEnumError("kind", kind.Kind, kinds))
+		}
+		e := newEvent()
+		if err := json.Unmarshal(raw, e); err != nil {
+			return nil, NewDecodeError("", ptr, err)
+		}
+		if err := l.Append(e); err != nil {
+			return nil, NewDecodeError("", ptr, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,46 @@
+package botc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGameLogCopiesEvents(t *testing.T) {
+	l := NewGameLog(nil, "Alice", "Bob", "Cat")
+	death := &DeathEvent{Seat: 0}
+	if err := l.Append(death); err != nil {
+		t.Fatal(err)
+	}
+	death.Seat = 2
+	if err := l.Append(&PhaseChangedEvent{Phase: NightPhase}); err != nil {
+		t.Fatal(err)
+	}
+	l.Events()[0].(*DeathEvent).Seat = 1
+
+	check := func(what string, g *Grimoire) {
+		t.Helper()
+		for i, want := range []bool{true, false, false} {
+			if g.Seats[i].Dead != want {
+				t.Errorf("%s: seat %d dead is %v, want %v", what, i, g.Seats[i].Dead, want)
+			}
+		}
+	}
+	check("state", l.State())
+	if !l.Undo() || !l.Undo() || !l.Redo() {
+		t.Fatal("could not undo and redo")
+	}
+	check("redo", l.State())
+	g, err := l.Replay(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("replay", g)
+
+	var buf bytes.Buffer
+	if err := l.WriteJSONLines(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"kind":"death","seat":0}`; !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("archive lost %s:\n%s", want, buf.Bytes())
+	}
+}
@@ -0,0 +1,378 @@
+package botc
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+)
+
+type Reminder struct {
+	Source string `json:"source"`
+	Text   string `json:"text"`
+}
+
+type Seat struct {
+	Player string
+	// Role is the character the player actually has.
+	Role *Role
+	// Displayed is the character the player was shown, when that differs
+	// from Role, as for the Drunk, Lunatic and Marionette.
+	Displayed     *Role
+	Alignment     Alignment
+	Dead          bool
+	GhostVoteUsed bool
+	Reminders     []Reminder
+}
+
+// Shown returns the character the player believes they are.
+func (s *Seat) Shown() *Role {
+	if s.Displayed != nil {
+		return s.Displayed
+	}
+	return s.Role
+}
+
+// CanVote reports whether the player may vote: the living always can, the
+// dead only until they spend their ghost vote.
+func (s *Seat) CanVote() bool {
+	return !s.Dead || !s.GhostVoteUsed
+}
+
+type Phase string
+
+const (
+	SetupPhase Phase = "setup"
+	NightPhase Phase = "night"
+	DayPhase   Phase = "day"
+)
+
+var phases = []Phase{SetupPhase, NightPhase, DayPhase}
+
+type Nomination struct {
+	Nominator int   `json:"nominator"`
+	Nominee   int   `json:"nominee"`
+	Votes     []int `json:"votes"`
+}
+
+var seatTeams = []RoleType{Townsfolk, Outsider, Minion, Demon, Traveller}
+
+// Grimoire is the state of a game in progress. Characters are looked up in
+// its source, usually the resolved script, both when placing reminders and
+// when a saved grimoire is loaded.
+type Grimoire struct {
+	Seats []Seat
+	Phase Phase
+	// Day counts nights and days together: the first night and the day
+	// after it are both day 1.
+	Day int
+	// Nominations are today's, cleared when a new day starts.
+	Nominations []Nomination
+	source      CharacterSource
+}
+
+func NewGrimoire(source CharacterSource, players ...string) *Grimoire {
+	g := &Grimoire{
+		Seats:       make([]Seat, len(players)),
+		Phase:       SetupPhase,
+		Nominations: []Nomination{},
+		source:      source,
+	}
+	for i, p := range players {
+		g.Seats[i] = Seat{Player: p, Alignment: None, Reminders: []Reminder{}}
+	}
+	return g
+}
+
+func (g *Grimoire) Source() CharacterSource {
+	if g.source == nil {
+		g.source = DefaultResolver()
+	}
+	return g.source
+}
+
+func (g *Grimoire) seat(i int) (*Seat, error) {
+	if i < 0 || i >= len(g.Seats) {
+		return nil, NewSeatError(i, len(g.Seats))
+	}
+	return &g.Seats[i], nil
+}
+
+func (g *Grimoire) Seat(i int) (*Seat, error) {
+	return g.seat(i)
+}
+
+func (g *Grimoire) Assign(i int, r *Role) error {
+	return g.AssignAs(i, r, nil)
+}
+
+// AssignAs gives seat i the character actual while showing the player
+// displayed instead. The seat takes the alignment of the actual character;
+// a traveller's alignment has to be set separately.
+func (g *Grimoire) AssignAs(i int, actual *Role, displayed *Role) error {
+	s, err := g.seat(i)
+	if err != nil {
+		return err
+	}
+	for _, r := range []*Role{actual, displayed} {
+		if r != nil && !slices.Contains(seatTeams, r.Team) {
+			return NewIllegalValueForEnumError("team", r.Team, seatTeams)
+		}
+	}
+	if displayed != nil && actual != nil && displayed.Id == actual.Id {
+		displayed = nil
+	}
+	s.Role = actual
+	s.Displayed = displayed
+	s.Alignment = None
+	if actual != nil {
+		s.Alignment = actual.Alignment()
+	}
+	return nil
+}
+
+func (g *Grimoire) SetAlignment(i int, a Alignment) error {
+	s, err := g.seat(i)
+	if err != nil {
+		return err
+	}
+	valid := []Alignment{Good, Evil}
+	if !slices.Contains(valid, a) {
+		return NewIllegalValueForEnumError("alignment", a, valid)
+	}
+	s.Alignment = a
+	return nil
+}
+
+func (g *Grimoire) Kill(i int) error {
+	s, err := g.seat(i)
+	if err != nil {
+		return err
+	}
+	s.Dead = true
+	return nil
+}
+
+func (g *Grimoire) Revive(i int) error {
+	s, err := g.seat(i)
+	if err != nil {
+		return err
+	}
+	s.Dead = false
+	s.GhostVoteUsed = false
+	return nil
+}
+
+// UseGhostVote spends a dead player's ghost vote, reporting false if they
+// are alive or have already used it.
+func (g *Grimoire) UseGhostVote(i int) (bool, error) {
+	s, err := g.seat(i)
+	if err != nil {
+		return false, err
+	}
+	if !s.Dead || s.GhostVoteUsed {
+		return false, nil
+	}
+	s.GhostVoteUsed = true
+	return true, nil
+}
+
+// PlaceReminder puts one of source's reminder tokens by seat i. The text must
+// be one of the character's reminders or global reminders.
+func (g *Grimoire) PlaceReminder(i int, source *Role, text string) error {
+	s, err := g.seat(i)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return NewRequiredFieldMissingError("source")
+	}
+	tokens := slices.Concat(source.ReminderTokens, source.GlobalReminders)
+	if !slices.Contains(tokens, text) {
+		return NewIllegalValueForEnumError("reminder", text, tokens)
+	}
+	s.Reminders = append(s.Reminders, Reminder{Source: source.Id, Text: text})
+	return nil
+}
+
+// RemoveReminder takes one matching token away from seat i, reporting whether
+// there was one to remove.
+func (g *Grimoire) RemoveReminder(i int, source string, text string) (bool, error) {
+	s, err := g.seat(i)
+	if err != nil {
+		return false, err
+	}
+	at := slices.Index(s.Reminders, Reminder{Source: CanonicalId(source), Text: text})
+	if at == -1 {
+		return false, nil
+	}
+	s.Reminders = slices.Delete(s.Reminders, at, at+1)
+	return true, nil
+}
+
+// InPlay lists the characters actually held by seated players, in seat
+// order and without repeats.
+func (g *Grimoire) InPlay() []*Role {
+	roles := make([]*Role, 0, len(g.Seats))
+	for _, s := range g.Seats {
+		if s.Role != nil && !containsRole(roles, s.Role.Id) {
+			roles = append(roles, s.Role)
+		}
+	}
+	return roles
+}
+
+// AvailableReminders lists the tokens the Storyteller may place: every
+// reminder of the characters in play and the global reminders of every
+// character in the grimoire's source.
+func (g *Grimoire) AvailableReminders() []Reminder {
+	reminders := make([]Reminder, 0)
+	for _, r := range g.InPlay() {
+		for _, t := range r.ReminderTokens {
+			reminders = append(reminders, Reminder{Source: r.Id, Text: t})
+		}
+	}
+	source := g.Source()
+	for _, id := range source.Ids() {
+		r, found := source.Lookup(id)
+		if !found {
+			continue
+		}
+		for _, t := range r.GlobalReminders {
+			if !slices.Contains(reminders, Reminder{Source: r.Id, Text: t}) {
+				reminders = append(reminders, Reminder{Source: r.Id, Text: t})
+			}
+		}
+	}
+	return reminders
+}
+
+func (g *Grimoire) Alive() int {
+	n := 0
+	for _, s := range g.Seats {
+		if !s.Dead {
+			n++
+		}
+	}
+	return n
+}
+
+type seatDocument struct {
+	Player        string     `json:"player"`
+	Character     string     `json:"character,omitempty"`
+	Displayed     string     `json:"displayed,omitempty"`
+	Alignment     Alignment  `json:"alignment"`
+	Dead          bool       `json:"dead"`
+	GhostVoteUsed bool       `json:"ghostVoteUsed"`
+	Reminders     []Reminder `json:"reminders"`
+}
+
+type grimoireDocument struct {
+	Seats       []seatDocument `json:"seats"`
+	Phase       Phase          `json:"phase"`
+	Day         int            `json:"day"`
+	Nominations []Nomination   `json:"nominations"`
+}
+
+func (g *Grimoire) MarshalJSON() ([]byte, error) {
+	doc := grimoireDocument{
+		Seats:       make([]seatDocument, len(g.Seats)),
+		Phase:       g.Phase,
+		Day:         g.Day,
+		Nominations: g.Nominations,
+	}
+	if doc.Phase == "" {
+		doc.Phase = SetupPhase
+	}
+	if doc.Nominations == nil {
+		doc.Nominations = []Nomination{}
+	}
+	for i, s := range g.Seats {
+		d := seatDocument{
+			Player:        s.Player,
+			Alignment:     s.Alignment,
+			Dead:          s.Dead,
+			GhostVoteUsed: s.GhostVoteUsed,
+			Reminders:     s.Reminders,
+		}
+		if d.Reminders == nil {
+			d.Reminders = []Reminder{}
+		}
+		if s.Role != nil {
+			d.Character = s.Role.Id
+		}
+		if s.Displayed != nil {
+			d.Displayed = s.Displayed.Id
+		}
+		doc.Seats[i] = d
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON restores a saved grimoire, resolving characters through the
+// grimoire's source, or the official catalogue if it has none. The grimoire
+// is left as it was if the document is not valid.
+func (g *Grimoire) UnmarshalJSON(data []byte) error {
+	var doc grimoireDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	phase := doc.Phase
+	if phase == "" {
+		phase = SetupPhase
+	}
+	if !slices.Contains(phases, phase) {
+		return NewDecodeError("", "/phase", NewIllegalValueForEnumError("phase", phase, phases))
+	}
+	source := g.Source()
+	lookup := func(id string, ptr string) (*Role, error) {
+		if id == "" {
+			return nil, nil
+		}
+		r, found := source.Lookup(id)
+		if !found {
+			return nil, NewDecodeError(id, ptr, NewUnknownCharacterError(id))
+		}
+		return r, nil
+	}
+	seats := make([]Seat, len(doc.Seats))
+	for i, d := range doc.Seats {
+		ptr := pointerJoin("/seats", strconv.Itoa(i))
+		role, err := lookup(d.Character, pointerJoin(ptr, "character"))
+		if err != nil {
+			return err
+		}
+		displayed, err := lookup(d.Displayed, pointerJoin(ptr, "displayed"))
+		if err != nil {
+			return err
+		}
+		alignment := d.Alignment
+		if alignment == "" {
+			alignment = None
+		}
+		if !slices.Contains(alignments, alignment) {
+			return NewDecodeError("", pointerJoin(ptr, "alignment"), NewIllegalValueForEnumError("alignment", alignment, alignments))
+		}
+		reminders := d.Reminders
+		if reminders == nil {
+			reminders = []Reminder{}
+		}
+		seats[i] = Seat{
+			Player:        d.Player,
+			Role:          role,
+			Displayed:     displayed,
+			Alignment:     alignment,
+			Dead:          d.Dead,
+			GhostVoteUsed: d.GhostVoteUsed,
+			Reminders:     reminders,
+		}
+	}
+	nominations := doc.Nominations
+	if nominations == nil {
+		nominations = []Nomination{}
+	}
+	g.Seats = seats
+	g.Phase = phase
+	g.Day = doc.Day
+	g.Nominations = nominations
+	return nil
+}
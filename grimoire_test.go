@@ -0,0 +1,82 @@
+package botc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGrimoireUnmarshalInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		path string
+	}{
+		{"phase", `{"seats":[{"player":"Zed","character":"imp","alignment":"Evil"}],"phase":"dusk","day":3}`, "/phase"},
+		{"alignment", `{"seats":[{"player":"Zed","character":"imp","alignment":"Chaotic"}],"phase":"day","day":3}`, "/seats/0/alignment"},
+		{"character", `{"seats":[{"player":"Zed","character":"nobody","alignment":"Evil"}],"phase":"day","day":3}`, "/seats/0/character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGrimoire(nil, "Alice", "Bob")
+			if err := g.Assign(0, OfficialRoster().CharacterIndex["empath"]); err != nil {
+				t.Fatal(err)
+			}
+			before, err := g.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = g.UnmarshalJSON([]byte(tt.doc))
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Path() != tt.path {
+				t.Fatalf("got %v, want an error at %s", err, tt.path)
+			}
+			after, err := g.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(after) != string(before) {
+				t.Errorf("a rejected document changed the grimoire:\n%s\n%s", before, after)
+			}
+		})
+	}
+}
+
+func TestGrimoireRoundTrip(t *testing.T) {
+	g := NewGrimoire(nil, "Alice", "Bob", "Cat")
+	official := OfficialRoster().CharacterIndex
+	if err := g.AssignAs(0, official["drunk"], official["empath"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Assign(1, official["imp"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Assign(2, official["gunslinger"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.PlaceReminder(1, official["imp"], "Dead"); err != nil {
+		t.Fatal(err)
+	}
+	g.Phase = NightPhase
+	g.Day = 2
+	data, err := g.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	back := NewGrimoire(nil)
+	if err := back.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back.Seats, g.Seats) || back.Phase != g.Phase || back.Day != g.Day {
+		t.Errorf("round trip gave %+v, want %+v", back, g)
+	}
+}
+
+func TestPlaceReminderNilSource(t *testing.T) {
+	g := NewGrimoire(nil, "Alice")
+	err := g.PlaceReminder(0, nil, "Dead")
+	var missing *RequiredFieldMissingError
+	if !errors.As(err, &missing) {
+		t.Errorf("got %v, want a missing source", err)
+	}
+}
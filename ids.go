@@ -0,0 +1,130 @@
+package botc
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IdNormaliser turns the many spellings of a character id found in scripts
+// and rosters ("high_priestess", "HighPriestess", "highpriestess_rah") into
+// one canonical id, following any aliases for renamed characters.
+type IdNormaliser struct {
+	mu      sync.RWMutex
+	aliases map[string]string
+}
+
+func NewIdNormaliser() *IdNormaliser {
+	return &IdNormaliser{
+		aliases: make(map[string]string),
+	}
+}
+
+var DefaultIds = NewIdNormaliser()
+
+func CanonicalId(id string) string {
+	return DefaultIds.Canonical(id)
+}
+
+func (n *IdNormaliser) AddAlias(alias string, id string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.aliases[normaliseId(alias)] = normaliseId(id)
+}
+
+func (n *IdNormaliser) Aliases() map[string]string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	aliases := make(map[string]string, len(n.aliases))
+	for k, v := range n.aliases {
+		aliases[k] = v
+	}
+	return aliases
+}
+
+func (n *IdNormaliser) Canonical(id string) string {
+	id = normaliseId(id)
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	// follow chains of renames, but never loop
+	seen := make(map[string]bool)
+	for !seen[id] {
+		seen[id] = true
+		target, found := n.aliases[id]
+		if !found {
+			break
+		}
+		id = target
+	}
+	return id
+}
+
+// normaliseId lowercases an id and drops the "_rah" suffix used by the
+// Released as Homebrew roster along with anything that isn't a letter, a
+// digit or a hyphen; custom ids such as "stowedaway-apprentice" keep their
+// hyphens, and letters outside ASCII are kept so "鬼" stays distinct.
+func normaliseId(id string) string {
+	id = strings.TrimSuffix(strings.ToLower(id), "_rah")
+	var b strings.Builder
+	for _, c := range id {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// SuggestIds returns up to max of the known ids closest to id by edit
+// distance, nearest first, ignoring any too far away to be a plausible typo.
+func SuggestIds(id string, known []string, max int) []string {
+	id = CanonicalId(id)
+	limit := utf8.RuneCountInString(id) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	type candidate struct {
+		id       string
+		distance int
+	}
+	candidates := make([]candidate, 0)
+	for _, k := range known {
+		d := editDistance(id, k)
+		if d <= limit && !slices.ContainsFunc(candidates, func(c candidate) bool { return c.id == k }) {
+			candidates = append(candidates, candidate{id: k, distance: d})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	suggestions := make([]string, 0, max)
+	for i := 0; i < len(candidates) && i < max; i++ {
+		suggestions = append(suggestions, candidates[i].id)
+	}
+	return suggestions
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
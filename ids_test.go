@@ -0,0 +1,43 @@
+package botc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCanonicalId(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"high_priestess", "highpriestess"},
+		{"HighPriestess", "highpriestess"},
+		{"highpriestess_rah", "highpriestess"},
+		{"stowedaway-apprentice", "stowedaway-apprentice"},
+		{"Lil' Monsta", "lilmonsta"},
+		{"鬼", "鬼"},
+		{"Ōni_2", "ōni2"},
+	}
+	for _, tt := range tests {
+		if got := CanonicalId(tt.id); got != tt.want {
+			t.Errorf("CanonicalId(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestPopulateIndexSuggests(t *testing.T) {
+	s, err := DecodeScript([]byte(`["washerwomn","chef",{"id":"鬼","name":"Oni","team":"demon","ability":"x"},{"id":"狐","name":"Fox","team":"minion","ability":"y"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := s.PopulateIndexFromRoster(OfficialRoster())
+	if !slices.Equal(res.Missing, []string{"washerwomn"}) {
+		t.Fatalf("missing %v", res.Missing)
+	}
+	if got := res.Suggestions["washerwomn"]; len(got) == 0 || got[0] != "washerwoman" {
+		t.Errorf("suggestions %v", got)
+	}
+	if s.Index["鬼"] == nil || s.Index["狐"] == nil || s.Index["鬼"] == s.Index["狐"] {
+		t.Errorf("custom characters collided in the index: %v", s.Index)
+	}
+}
@@ -1,11 +1,87 @@
 package botc
 
+import (
+	"slices"
+	"strings"
+)
+
 type Jinx struct {
 	Id     string `json:"id"`
 	Reason string `json:"reason"`
 }
 
-func extractJinxes(m map[string]any) (map[string]string, error) {
+func (j Jinx) MarshalJSON() ([]byte, error) {
+	e := newObjectEncoder()
+	j.encodeFields(e.field)
+	return e.bytes()
+}
+
+func (j Jinx) encodeFields(emit func(k string, v any)) {
+	emit("id", j.Id)
+	emit("reason", j.Reason)
+}
+
+// Jinxes keeps a character's jinxes in the order they were declared, so
+// encoding a role writes them back the way they were read. Encoding never
+// reorders them; see Sorted for output in a canonical order.
+type Jinxes []Jinx
+
+// Get finds the reason for the jinx with id, comparing canonical ids.
+func (js Jinxes) Get(id string) (string, bool) {
+	if i := js.index(id); i != -1 {
+		return js[i].Reason, true
+	}
+	return "", false
+}
+
+func (js Jinxes) index(id string) int {
+	if i := slices.IndexFunc(js, func(j Jinx) bool { return j.Id == id }); i != -1 {
+		return i
+	}
+	id = CanonicalId(id)
+	return slices.IndexFunc(js, func(j Jinx) bool { return CanonicalId(j.Id) == id })
+}
+
+// Set replaces the reason of an existing jinx with id where it stands, or
+// adds a new one at the end.
+func (js *Jinxes) Set(id string, reason string) {
+	if i := js.index(id); i != -1 {
+		(*js)[i].Reason = reason
+		return
+	}
+	*js = append(*js, Jinx{Id: id, Reason: reason})
+}
+
+func (js *Jinxes) Delete(id string) bool {
+	i := js.index(id)
+	if i == -1 {
+		return false
+	}
+	*js = slices.Delete(*js, i, i+1)
+	return true
+}
+
+func (js Jinxes) Ids() []string {
+	ids := make([]string, len(js))
+	for i, j := range js {
+		ids[i] = j.Id
+	}
+	return ids
+}
+
+// Sorted returns a copy in canonical order, by canonical id, for output
+// that should not depend on how the jinxes were written. Jinxes whose ids
+// canonicalise the same keep their declared order. To encode a role in
+// canonical order, assign the result to its Jinxes before marshalling.
+func (js Jinxes) Sorted() Jinxes {
+	sorted := slices.Clone(js)
+	slices.SortStableFunc(sorted, func(a, b Jinx) int {
+		return strings.Compare(CanonicalId(a.Id), CanonicalId(b.Id))
+	})
+	return sorted
+}
+
+func extractJinxes(m map[string]any) (Jinxes, error) {
 	raw, ok, err := extractSlice("jinxes", m)
 	if !ok {
 		return nil, nil
@@ -13,20 +89,22 @@ func extractJinxes(m map[string]any) (map[string]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	jinxes := make(map[string]string)
+	jinxes := make(Jinxes, 0, len(raw))
 
 	for _, item := range raw {
-		var id, reason string
-		for k, v := range item.(map[string]any) {
-			val := v.(string)
-			switch k {
-			case "id":
-				id = val
-			case "reason":
-				reason = val
-			}
+		jm, ok := item.(map[string]any)
+		if !ok {
+			return jinxes, NewConversionError("jinxes", item)
 		}
-		jinxes[id] = reason
+		id, err := extractRequiredString("id", jm)
+		if err != nil {
+			return jinxes, err
+		}
+		reason, err := extractRequiredString("reason", jm)
+		if err != nil {
+			return jinxes, err
+		}
+		jinxes = append(jinxes, Jinx{Id: id, Reason: reason})
 	}
 	return jinxes, nil
 }
@@ -39,15 +117,147 @@ func JinxesToMap(js []Jinx) map[string]string {
 	return m
 }
 
+// JinxesFromMap builds jinxes from the map form, sorted by id since a map
+// has no order of its own.
 func JinxesFromMap(m map[string]string) []Jinx {
-	js := make([]Jinx, len(m))
-	i := 0
+	js := make([]Jinx, 0, len(m))
 	for id, reason := range m {
-		js[i] = Jinx{
+		js = append(js, Jinx{
 			Id:     id,
 			Reason: reason,
+		})
+	}
+	return Jinxes(js).Sorted()
+}
+
+func jinxesToMaps(js []Jinx) []map[string]string {
+	ms := make([]map[string]string, len(js))
+	for i, j := range js {
+		ms[i] = map[string]string{
+			"id":     j.Id,
+			"reason": j.Reason,
+		}
+	}
+	return ms
+}
+
+// JinxKey identifies a jinx by the canonical ids of its two characters in
+// sorted order, so either way round finds the same jinx.
+type JinxKey struct {
+	A string
+	B string
+}
+
+func NewJinxKey(a string, b string) JinxKey {
+	a, b = CanonicalId(a), CanonicalId(b)
+	if b < a {
+		a, b = b, a
+	}
+	return JinxKey{A: a, B: b}
+}
+
+// JinxPair is a jinx between two characters, A sorting before B.
+// DeclaredBy lists which of them carry the jinx in their own definition,
+// and Official is set when the bundled table has it too. A declared reason
+// takes precedence over the official one.
+type JinxPair struct {
+	A          string   `json:"a"`
+	B          string   `json:"b"`
+	Reason     string   `json:"reason"`
+	DeclaredBy []string `json:"declaredBy"`
+	Official   bool     `json:"official"`
+}
+
+// DanglingJinx is a jinx declared against a character that is not amongst
+// those analysed. Jinxes that only repeat the official table are not
+// dangling, since official characters carry jinxes with the whole catalogue.
+type DanglingJinx struct {
+	Id     string `json:"id"`
+	Target string `json:"target"`
+	Reason string `json:"reason"`
+}
+
+// JinxConflict is a pair of characters that each declare the jinx between
+// them with different text.
+type JinxConflict struct {
+	A       string `json:"a"`
+	B       string `json:"b"`
+	ReasonA string `json:"reasonA"`
+	ReasonB string `json:"reasonB"`
+}
+
+type JinxReport struct {
+	Active    []JinxPair     `json:"active"`
+	Dangling  []DanglingJinx `json:"dangling"`
+	Conflicts []JinxConflict `json:"conflicts"`
+}
+
+// Jinxes analyses every jinx between the characters on a resolved script.
+func (s *Script) Jinxes() JinxReport {
+	roles := make([]*Role, 0, len(s.Index))
+	for _, id := range s.Ids() {
+		roles = append(roles, s.Index[id])
+	}
+	return AnalyseJinxes(roles)
+}
+
+// AnalyseJinxes finds the jinxes that apply between roles, whichever side
+// declares them or from the official table, along with those pointing
+// outside roles and those declared differently on each side. Results are
+// sorted by id.
+func AnalyseJinxes(roles []*Role) JinxReport {
+	report := JinxReport{
+		Active:    []JinxPair{},
+		Dangling:  []DanglingJinx{},
+		Conflicts: []JinxConflict{},
+	}
+	byId := make(map[string]*Role, len(roles))
+	ids := make([]string, 0, len(roles))
+	for _, r := range roles {
+		id := CanonicalId(r.Id)
+		if _, found := byId[id]; !found {
+			ids = append(ids, id)
+		}
+		byId[id] = r
+	}
+	slices.Sort(ids)
+
+	for i, a := range ids {
+		ra := byId[a]
+		for _, b := range ids[i+1:] {
+			rb := byId[b]
+			textA, fromA := ra.declaredJinx(b)
+			textB, fromB := rb.declaredJinx(a)
+			official, isOfficial := OfficialJinx(a, b)
+			if !fromA && !fromB && !isOfficial {
+				continue
+			}
+			pair := JinxPair{A: a, B: b, Reason: official, DeclaredBy: []string{}, Official: isOfficial}
+			if fromA {
+				pair.Reason = textA
+				pair.DeclaredBy = append(pair.DeclaredBy, a)
+			}
+			if fromB {
+				if !fromA {
+					pair.Reason = textB
+				}
+				pair.DeclaredBy = append(pair.DeclaredBy, b)
+			}
+			report.Active = append(report.Active, pair)
+			if fromA && fromB && strings.TrimSpace(textA) != strings.TrimSpace(textB) {
+				report.Conflicts = append(report.Conflicts, JinxConflict{A: a, B: b, ReasonA: textA, ReasonB: textB})
+			}
+		}
+		for _, j := range ra.Jinxes.Sorted() {
+			target := CanonicalId(j.Id)
+			if _, found := byId[target]; found {
+				continue
+			}
+			if official, isOfficial := OfficialJinx(a, target); isOfficial && official == j.Reason {
+				continue
+			}
+			report.Dangling = append(report.Dangling, DanglingJinx{Id: a, Target: target, Reason: j.Reason})
 		}
-		i++
 	}
-	return js
+	return report
 }
@@ -0,0 +1,123 @@
+package botc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAnalyseJinxesDangling(t *testing.T) {
+	s := loadScript(t, "asset/Stowed Away.json")
+	if report := s.Jinxes(); len(report.Dangling) != 0 {
+		t.Errorf("official jinxes reported as dangling: %+v", report.Dangling)
+	}
+
+	spy := *OfficialRoster().CharacterIndex["spy"]
+	spy.Jinxes = append(Jinxes{}, spy.Jinxes...)
+	spy.Jinxes.Set("damsel", "The Damsel is poisoned while the Spy lives.")
+	custom := &Role{Id: "mole", Name: "Mole", Team: Minion, Jinxes: Jinxes{
+		{Id: "nobody", Reason: "Nothing happens."},
+	}}
+	report := AnalyseJinxes([]*Role{&spy, custom})
+	want := []DanglingJinx{
+		{Id: "mole", Target: "nobody", Reason: "Nothing happens."},
+		{Id: "spy", Target: "damsel", Reason: "The Damsel is poisoned while the Spy lives."},
+	}
+	if !slices.Equal(report.Dangling, want) {
+		t.Errorf("dangling %+v, want %+v", report.Dangling, want)
+	}
+}
+
+const homebrewAlchemist = `{"id":"alchemist","name":"Alchemist","team":"townsfolk","ability":"x",` +
+	`"jinxes":[{"id":"marionette","reason":"The Alchemist keeps the Marionette ability."}]}`
+
+func TestJinxWithOverride(t *testing.T) {
+	s, err := DecodeScript([]byte(`[` + homebrewAlchemist + `,"marionette","poisoner"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := s.PopulateOfficialIndex(); len(missing) > 0 {
+		t.Fatalf("missing %v", missing)
+	}
+	want := "The Alchemist keeps the Marionette ability."
+	alchemist, marionette := s.Index["alchemist"], s.Index["marionette"]
+	if reason, _ := alchemist.JinxWith(marionette); reason != want {
+		t.Errorf("Alchemist with Marionette: got %q", reason)
+	}
+	if reason, _ := marionette.JinxWith(alchemist); reason != want {
+		t.Errorf("Marionette with Alchemist: got %q", reason)
+	}
+	if reason, _ := s.JinxWith("Marionette", "alchemist"); reason != want {
+		t.Errorf("script: got %q", reason)
+	}
+	if _, found := s.JinxWith("alchemist", "poisoner"); found {
+		t.Error("found a jinx between the Alchemist and the Poisoner")
+	}
+}
+
+func TestScriptJinxWithOffScript(t *testing.T) {
+	official, found := OfficialJinx("alchemist", "marionette")
+	if !found {
+		t.Fatal("no official Alchemist-Marionette jinx")
+	}
+	// the script's own Alchemist declares a jinx, but without the
+	// Marionette on the script the official table answers
+	s, err := DecodeScript([]byte(`[` + homebrewAlchemist + `,"poisoner"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.PopulateOfficialIndex()
+	for _, pair := range [][2]string{{"alchemist", "marionette"}, {"marionette", "alchemist"}, {"marionette", "Alchemist"}} {
+		if reason, found := s.JinxWith(pair[0], pair[1]); !found || reason != official {
+			t.Errorf("%v: got %q, %v, want the official jinx", pair, reason, found)
+		}
+	}
+	if _, found := s.JinxWith("poisoner", "marionette"); found {
+		t.Error("found a jinx between the Poisoner and the Marionette")
+	}
+}
+
+func TestJinxesSorted(t *testing.T) {
+	js := Jinxes{
+		{Id: "Spy", Reason: "first spy"},
+		{Id: "lil_monsta", Reason: "monsta"},
+		{Id: "alchemist", Reason: "alchemist"},
+		{Id: "spy", Reason: "second spy"},
+	}
+	declared := slices.Clone(js)
+	sorted := js.Sorted()
+	want := Jinxes{
+		{Id: "alchemist", Reason: "alchemist"},
+		{Id: "lil_monsta", Reason: "monsta"},
+		{Id: "Spy", Reason: "first spy"},
+		{Id: "spy", Reason: "second spy"},
+	}
+	if !slices.Equal(sorted, want) {
+		t.Errorf("sorted %v, want %v", sorted, want)
+	}
+	if !slices.Equal(js, declared) {
+		t.Errorf("Sorted reordered its receiver: %v", js)
+	}
+
+	r := Role{Id: "x", Name: "X", Team: Townsfolk, Ability: "y", FirstNightOrder: -1, OtherNightOrder: -1, Jinxes: js}
+	out, err := r.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); !strings.Contains(got, `"jinxes":[{"id":"Spy",`) {
+		t.Errorf("encoding reordered the jinxes: %s", got)
+	}
+	r.Jinxes = r.Jinxes.Sorted()
+	out, err = r.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); !strings.Contains(got, `"jinxes":[{"id":"alchemist",`) {
+		t.Errorf("sorted jinxes encoded out of order: %s", got)
+	}
+
+	fromMap := JinxesFromMap(map[string]string{"spy": "s", "Alchemist": "a", "imp": "i"})
+	if ids := Jinxes(fromMap).Ids(); !slices.Equal(ids, []string{"Alchemist", "imp", "spy"}) {
+		t.Errorf("JinxesFromMap order %v", ids)
+	}
+}
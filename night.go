@@ -0,0 +1,203 @@
+package botc
+
+import (
+	"cmp"
+	"slices"
+)
+
+type TieBreak func(a, b NightOrdered) int
+
+func TieBreakById(a, b NightOrdered) int {
+	return cmp.Compare(nightId(a), nightId(b))
+}
+
+func TieBreakByName(a, b NightOrdered) int {
+	return cmp.Or(
+		cmp.Compare(a.GetName(), b.GetName()),
+		TieBreakById(a, b),
+	)
+}
+
+type NightOrderTie struct {
+	Priority float64  `json:"priority"`
+	Ids      []string `json:"ids"`
+	// Collision is set when custom characters share the priority with
+	// official characters or night events.
+	Collision bool `json:"collision"`
+	// Resolved is set when the script's own night order names every id in
+	// the tie, so the tie-break policy played no part.
+	Resolved bool `json:"resolved"`
+}
+
+type NightOrderReport struct {
+	Order   []NightOrdered  `json:"order"`
+	Ties    []NightOrderTie `json:"ties"`
+	Unknown []string        `json:"unknown"`
+}
+
+type NightOrderBuilder struct {
+	script   *Script
+	tieBreak TieBreak
+}
+
+func NewNightOrderBuilder(s *Script) *NightOrderBuilder {
+	return &NightOrderBuilder{
+		script:   s,
+		tieBreak: TieBreakById,
+	}
+}
+
+func (b *NightOrderBuilder) WithTieBreak(t TieBreak) *NightOrderBuilder {
+	b.tieBreak = t
+	return b
+}
+
+func (b *NightOrderBuilder) FirstNight() NightOrderReport {
+	return b.build(b.script.Meta.FirstNight, firstNight, NightOrdered.GetFirstNightOrder)
+}
+
+func (b *NightOrderBuilder) OtherNights() NightOrderReport {
+	return b.build(b.script.Meta.OtherNight, otherNights, NightOrdered.GetOtherNightsOrder)
+}
+
+func nightId(n NightOrdered) string {
+	switch v := n.(type) {
+	case *Role:
+		return v.Id
+	case Event:
+		return v.String()
+	default:
+		return n.GetName()
+	}
+}
+
+func (b *NightOrderBuilder) candidates(events map[Event]float64) map[string]NightOrdered {
+	candidates := make(map[string]NightOrdered)
+	for e := range events {
+		candidates[e.String()] = e
+	}
+	for id, role := range b.script.Index {
+		if role != nil {
+			candidates[id] = role
+		}
+	}
+	return candidates
+}
+
+func lookupNightId(candidates map[string]NightOrdered, id string) (string, bool) {
+	if _, found := candidates[id]; found {
+		return id, true
+	}
+	id = CanonicalId(id)
+	_, found := candidates[id]
+	return id, found
+}
+
+// build follows the script's explicit order for the ids it names and slots
+// everything else in by its numeric priority, settling equal priorities with
+// the tie-break policy. Characters with no priority, or a priority of 0,
+// don't wake unless the script names them.
+func (b *NightOrderBuilder) build(explicit []string, events map[Event]float64, priority func(NightOrdered) float64) NightOrderReport {
+	candidates := b.candidates(events)
+	report := NightOrderReport{
+		Ties:    make([]NightOrderTie, 0),
+		Unknown: make([]string, 0),
+	}
+
+	used := make(map[string]bool)
+	named := make([]NightOrdered, 0, len(explicit))
+	for _, id := range explicit {
+		key, found := lookupNightId(candidates, id)
+		if !found {
+			report.Unknown = append(report.Unknown, id)
+			continue
+		}
+		if !used[key] {
+			named = append(named, candidates[key])
+			used[key] = true
+		}
+	}
+	rest := make([]NightOrdered, 0)
+	for id, n := range candidates {
+		if !used[id] && priority(n) > 0 {
+			rest = append(rest, n)
+		}
+	}
+	slices.SortFunc(rest, func(x, y NightOrdered) int {
+		return cmp.Or(cmp.Compare(priority(x), priority(y)), b.tieBreak(x, y))
+	})
+
+	// a named entry is never earlier than the one the script put before it
+	effective := make([]float64, len(named))
+	for i, n := range named {
+		effective[i] = priority(n)
+		if i > 0 && effective[i-1] > effective[i] {
+			effective[i] = effective[i-1]
+		}
+	}
+	report.Order = make([]NightOrdered, 0, len(named)+len(rest))
+	i, j := 0, 0
+	for i < len(named) && j < len(rest) {
+		if effective[i] <= priority(rest[j]) {
+			report.Order = append(report.Order, named[i])
+			i++
+		} else {
+			report.Order = append(report.Order, rest[j])
+			j++
+		}
+	}
+	report.Order = append(report.Order, named[i:]...)
+	report.Order = append(report.Order, rest[j:]...)
+
+	report.Ties = b.ties(report.Order, used, priority)
+	return report
+}
+
+func (b *NightOrderBuilder) ties(order []NightOrdered, named map[string]bool, priority func(NightOrdered) float64) []NightOrderTie {
+	custom := make(map[string]bool)
+	for _, c := range b.script.CustomCharacters {
+		custom[c.Id] = true
+	}
+	groups := make(map[float64][]NightOrdered)
+	priorities := make([]float64, 0)
+	for _, n := range order {
+		p := priority(n)
+		if p <= 0 {
+			continue
+		}
+		if _, found := groups[p]; !found {
+			priorities = append(priorities, p)
+		}
+		groups[p] = append(groups[p], n)
+	}
+	slices.Sort(priorities)
+
+	ties := make([]NightOrderTie, 0)
+	for _, p := range priorities {
+		group := groups[p]
+		if len(group) < 2 {
+			continue
+		}
+		tie := NightOrderTie{
+			Priority: p,
+			Ids:      make([]string, len(group)),
+			Resolved: true,
+		}
+		hasCustom, hasOfficial := false, false
+		for i, n := range group {
+			id := nightId(n)
+			tie.Ids[i] = id
+			if custom[id] {
+				hasCustom = true
+			} else {
+				hasOfficial = true
+			}
+			if !named[id] {
+				tie.Resolved = false
+			}
+		}
+		tie.Collision = hasCustom && hasOfficial
+		ties = append(ties, tie)
+	}
+	return ties
+}
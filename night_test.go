@@ -0,0 +1,208 @@
+package botc
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func nightIds(order []NightOrdered) []string {
+	ids := make([]string, len(order))
+	for i, n := range order {
+		ids[i] = nightId(n)
+	}
+	return ids
+}
+
+// nightScript has two characters tied at 10, one sharing 19 with the minion
+// info event, and one with no first-night priority at all.
+func nightScript(t *testing.T, meta string) *Script {
+	t.Helper()
+	doc := `[` + meta +
+		`{"id":"alpha","name":"Zebra","team":"townsfolk","ability":"a","firstNight":10,"otherNight":5},` +
+		`{"id":"beta","name":"Aardvark","team":"townsfolk","ability":"b","firstNight":10,"otherNight":0},` +
+		`{"id":"gamma","name":"Gamma","team":"minion","ability":"c","firstNight":30},` +
+		`{"id":"delta","name":"Delta","team":"demon","ability":"d","otherNight":20},` +
+		`{"id":"epsilon","name":"Epsilon","team":"outsider","ability":"e","firstNight":19}]`
+	s, err := DecodeScript([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.PopulateOfficialIndex()
+	return &s
+}
+
+func TestNightOrderBuilder(t *testing.T) {
+	unresolved := NightOrderTie{Priority: 19, Ids: []string{"epsilon", "minioninfo"}, Collision: true}
+	tests := []struct {
+		name     string
+		meta     string
+		tieBreak TieBreak
+		other    bool
+		order    []string
+		ties     []NightOrderTie
+		unknown  []string
+	}{
+		{
+			name:  "priorities only",
+			order: []string{"dusk", "alpha", "beta", "epsilon", "minioninfo", "demoninfo", "gamma", "dawn"},
+			ties: []NightOrderTie{
+				{Priority: 10, Ids: []string{"alpha", "beta"}},
+				unresolved,
+			},
+		},
+		{
+			name:     "tie broken by name",
+			tieBreak: TieBreakByName,
+			order:    []string{"dusk", "beta", "alpha", "epsilon", "minioninfo", "demoninfo", "gamma", "dawn"},
+			ties: []NightOrderTie{
+				{Priority: 10, Ids: []string{"beta", "alpha"}},
+				unresolved,
+			},
+		},
+		{
+			name:  "script resolves a tie and wakes a character with no priority",
+			meta:  `{"id":"_meta","name":"x","firstNight":["beta","alpha","delta"]},`,
+			order: []string{"dusk", "beta", "alpha", "delta", "epsilon", "minioninfo", "demoninfo", "gamma", "dawn"},
+			ties: []NightOrderTie{
+				{Priority: 10, Ids: []string{"beta", "alpha"}, Resolved: true},
+				unresolved,
+			},
+		},
+		{
+			name:  "script moves a character later than its priority",
+			meta:  `{"id":"_meta","name":"x","firstNight":["GAMMA","alpha","nobody"]},`,
+			order: []string{"dusk", "beta", "epsilon", "minioninfo", "demoninfo", "gamma", "alpha", "dawn"},
+			ties: []NightOrderTie{
+				{Priority: 10, Ids: []string{"beta", "alpha"}},
+				unresolved,
+			},
+			unknown: []string{"nobody"},
+		},
+		{
+			name:  "other nights skip missing and zero priorities",
+			other: true,
+			order: []string{"dusk", "alpha", "delta", "dawn"},
+		},
+		{
+			name:  "other nights follow the script",
+			meta:  `{"id":"_meta","name":"x","otherNight":["delta","dusk","alpha"]},`,
+			other: true,
+			order: []string{"delta", "dusk", "alpha", "dawn"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewNightOrderBuilder(nightScript(t, tt.meta))
+			if tt.tieBreak != nil {
+				b.WithTieBreak(tt.tieBreak)
+			}
+			var report NightOrderReport
+			if tt.other {
+				report = b.OtherNights()
+			} else {
+				report = b.FirstNight()
+			}
+			if got := nightIds(report.Order); !slices.Equal(got, tt.order) {
+				t.Errorf("order %v, want %v", got, tt.order)
+			}
+			if tt.ties == nil {
+				tt.ties = []NightOrderTie{}
+			}
+			if !reflect.DeepEqual(report.Ties, tt.ties) {
+				t.Errorf("ties %+v, want %+v", report.Ties, tt.ties)
+			}
+			if tt.unknown == nil {
+				tt.unknown = []string{}
+			}
+			if !slices.Equal(report.Unknown, tt.unknown) {
+				t.Errorf("unknown %v, want %v", report.Unknown, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestNightOrderBuilderAsset(t *testing.T) {
+	s := loadScript(t, "asset/Sects & Violets.json")
+	b := NewNightOrderBuilder(s)
+	for _, tt := range []struct {
+		name   string
+		report NightOrderReport
+		meta   []string
+	}{
+		{"first night", b.FirstNight(), s.Meta.FirstNight},
+		{"other nights", b.OtherNights(), s.Meta.OtherNight},
+	} {
+		// the script also orders travellers and fabled it doesn't list
+		onScript := make([]string, 0, len(tt.meta))
+		offScript := make([]string, 0)
+		for _, id := range tt.meta {
+			if _, found := s.Index[id]; found || Event(id).GetName() != "" {
+				onScript = append(onScript, id)
+			} else {
+				offScript = append(offScript, id)
+			}
+		}
+		if !slices.Equal(tt.report.Unknown, offScript) {
+			t.Errorf("%s: unknown %v, want %v", tt.name, tt.report.Unknown, offScript)
+		}
+		named := slices.DeleteFunc(nightIds(tt.report.Order), func(id string) bool {
+			return !slices.Contains(tt.meta, id)
+		})
+		if !slices.Equal(named, onScript) {
+			t.Errorf("%s: script order not followed\ngot  %v\nwant %v", tt.name, named, onScript)
+		}
+		for _, tie := range tt.report.Ties {
+			if tie.Collision {
+				t.Errorf("%s: collision with no custom characters: %+v", tt.name, tie)
+			}
+		}
+	}
+}
+
+func TestFractionalNightPriorities(t *testing.T) {
+	doc := `[{"id":"later","name":"Later","team":"townsfolk","ability":"a","firstNight":13,"otherNight":2.25},` +
+		`{"id":"half","name":"Half","team":"townsfolk","ability":"b","firstNight":12.5,"otherNight":2},` +
+		`{"id":"whole","name":"Whole","team":"townsfolk","ability":"c","firstNight":12,"otherNight":2.125}]`
+	s, err := DecodeScript([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.PopulateOfficialIndex()
+
+	want := map[string][2]float64{"later": {13, 2.25}, "half": {12.5, 2}, "whole": {12, 2.125}}
+	for id, w := range want {
+		r := s.Index[id]
+		if r.FirstNightOrder != w[0] || r.OtherNightOrder != w[1] {
+			t.Errorf("%s decoded as %v/%v, want %v/%v", id, r.FirstNightOrder, r.OtherNightOrder, w[0], w[1])
+		}
+	}
+
+	first := nightIds(s.FirstNight())
+	if want := []string{"dusk", "whole", "half", "later", "minioninfo", "demoninfo", "dawn"}; !slices.Equal(first, want) {
+		t.Errorf("first night %v, want %v", first, want)
+	}
+	other := nightIds(s.OtherNights())
+	if want := []string{"dusk", "half", "whole", "later", "dawn"}; !slices.Equal(other, want) {
+		t.Errorf("other nights %v, want %v", other, want)
+	}
+
+	out, err := s.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != doc {
+		t.Errorf("re-encoded as\n%s\nwant\n%s", out, doc)
+	}
+	r, err := NewRole(map[string]any{"id": "x", "name": "X", "team": "townsfolk", "ability": "a", "firstNight": 12.0, "otherNight": 12.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = r.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":"x","name":"X","team":"townsfolk","ability":"a","firstNight":12,"otherNight":12.5}`; string(out) != want {
+		t.Errorf("encoded as %s, want %s", out, want)
+	}
+}
@@ -0,0 +1,167 @@
+package botc
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// minInfoPlayers is the smallest game in which the Minions and Demon learn
+// each other on the first night.
+const minInfoPlayers = 7
+
+type NightStep struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Seats    []int    `json:"seats"`
+	Players  []string `json:"players"`
+	Reminder string   `json:"reminder"`
+}
+
+type NightSheet struct {
+	First bool        `json:"first"`
+	Day   int         `json:"day"`
+	Steps []NightStep `json:"steps"`
+}
+
+func (s *Script) FirstNightSheet(g *Grimoire) NightSheet {
+	return s.nightSheet(g, true, NewNightOrderBuilder(s).FirstNight().Order)
+}
+
+func (s *Script) OtherNightSheet(g *Grimoire) NightSheet {
+	return s.nightSheet(g, false, NewNightOrderBuilder(s).OtherNights().Order)
+}
+
+// NightSheet is the sheet for tonight, or for the coming night during the
+// day: the first night sheet until the first day has begun.
+func (s *Script) NightSheet(g *Grimoire) NightSheet {
+	if g.Day <= 1 && g.Phase != DayPhase {
+		return s.FirstNightSheet(g)
+	}
+	return s.OtherNightSheet(g)
+}
+
+// nightSheet keeps the steps of order that matter in this game: characters
+// held or believed held by a seated player, leaving out the dead unless their
+// ability works when dead, and night events.
+func (s *Script) nightSheet(g *Grimoire, first bool, order []NightOrdered) NightSheet {
+	sheet := NightSheet{
+		First: first,
+		Day:   g.Day,
+		Steps: []NightStep{},
+	}
+	players := 0
+	for _, seat := range g.Seats {
+		if seat.Role == nil || seat.Role.Team != Traveller {
+			players++
+		}
+	}
+	names := g.playerNames()
+	for _, n := range order {
+		switch v := n.(type) {
+		case Event:
+			if (v == MinionInfo || v == DemonInfo) && players < minInfoPlayers {
+				continue
+			}
+			sheet.Steps = append(sheet.Steps, NightStep{
+				Id:      v.String(),
+				Name:    v.GetName(),
+				Seats:   []int{},
+				Players: []string{},
+			})
+		case *Role:
+			step := NightStep{
+				Id:      v.Id,
+				Name:    v.Name,
+				Seats:   []int{},
+				Players: []string{},
+			}
+			for i, seat := range g.Seats {
+				if !seat.wakesAs(v) {
+					continue
+				}
+				if seat.Dead && !v.ParsedAbility().WorksWhenDead() {
+					continue
+				}
+				step.Seats = append(step.Seats, i)
+				step.Players = append(step.Players, seat.Player)
+			}
+			if len(step.Seats) == 0 {
+				continue
+			}
+			reminder := v.OtherNightReminder
+			if first {
+				reminder = v.FirstNightReminder
+			}
+			bound := withPlayers(names, v.Name, step.Players)
+			step.Reminder = bindPlayers(stripReminderMarker(reminder), bound)
+			sheet.Steps = append(sheet.Steps, step)
+		}
+	}
+	return sheet
+}
+
+// wakesAs reports whether the seat's player is woken for r, either because
+// they have it or because they think they do.
+func (s *Seat) wakesAs(r *Role) bool {
+	return (s.Role != nil && s.Role.Id == r.Id) || (s.Displayed != nil && s.Displayed.Id == r.Id)
+}
+
+// playerNames maps each character name in play, and "Demon", to the players
+// who actually hold it.
+func (g *Grimoire) playerNames() map[string][]string {
+	names := make(map[string][]string)
+	for _, seat := range g.Seats {
+		if seat.Role == nil {
+			continue
+		}
+		names[seat.Role.Name] = append(names[seat.Role.Name], seat.Player)
+		if seat.Role.Team == Demon {
+			names[Demon.Name()] = append(names[Demon.Name()], seat.Player)
+		}
+	}
+	return names
+}
+
+func withPlayers(names map[string][]string, name string, players []string) map[string][]string {
+	bound := make(map[string][]string, len(names)+1)
+	for k, v := range names {
+		bound[k] = v
+	}
+	bound[name] = players
+	return bound
+}
+
+var reminderMarkerSpace = regexp.MustCompile(`[ \t]*` + regexp.QuoteMeta(reminderMarker))
+
+// stripReminderMarker drops the markers the official tool draws as a
+// reminder icon, which read as noise in plain text.
+func stripReminderMarker(text string) string {
+	return strings.TrimSpace(reminderMarkerSpace.ReplaceAllString(text, ""))
+}
+
+// bindPlayers follows the first mention of each character name in text
+// with the players who hold it, so "The Poisoner chooses a player" reads
+// "The Poisoner (Alice) chooses a player".
+func bindPlayers(text string, names map[string][]string) string {
+	if text == "" || len(names) == 0 {
+		return text
+	}
+	keys := make([]string, 0, len(names))
+	for k := range names {
+		keys = append(keys, regexp.QuoteMeta(k))
+	}
+	// longer names first so "Evil Twin" wins over "Twin"
+	slices.SortFunc(keys, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	re := regexp.MustCompile(`\b(` + strings.Join(keys, "|") + `)\b`)
+	bound := make(map[string]bool)
+	return re.ReplaceAllStringFunc(text, func(name string) string {
+		if bound[name] {
+			return name
+		}
+		bound[name] = true
+		return name + " (" + strings.Join(names[name], ", ") + ")"
+	})
+}
@@ -0,0 +1,120 @@
+package botc
+
+import (
+	"strings"
+	"testing"
+)
+
+func nightSheetGame(t *testing.T) (*Script, *Grimoire) {
+	t.Helper()
+	var s Script
+	doc := `["washerwoman","empath","ravenkeeper","drunk","poisoner","imp"]`
+	if err := s.UnmarshalJSON([]byte(doc)); err != nil {
+		t.Fatal(err)
+	}
+	if missing := s.PopulateOfficialIndex(); len(missing) > 0 {
+		t.Fatalf("missing %v", missing)
+	}
+	g := NewGrimoire(&s, "Alice", "Bob", "Cat", "Dan", "Eve")
+	assign := func(i int, actual, displayed string) {
+		var shown *Role
+		if displayed != "" {
+			shown = s.Index[displayed]
+		}
+		if err := g.AssignAs(i, s.Index[actual], shown); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assign(0, "washerwoman", "")
+	assign(1, "empath", "")
+	assign(2, "drunk", "ravenkeeper")
+	assign(3, "poisoner", "")
+	assign(4, "imp", "")
+	return &s, g
+}
+
+func stepIds(sheet NightSheet) []string {
+	ids := make([]string, len(sheet.Steps))
+	for i, st := range sheet.Steps {
+		ids[i] = st.Id
+	}
+	return ids
+}
+
+func TestNightSheetPhase(t *testing.T) {
+	tests := []struct {
+		phase Phase
+		day   int
+		first bool
+	}{
+		{SetupPhase, 0, true},
+		{NightPhase, 1, true},
+		{DayPhase, 1, false},
+		{NightPhase, 2, false},
+		{DayPhase, 2, false},
+	}
+	for _, tt := range tests {
+		s, g := nightSheetGame(t)
+		g.Phase = tt.phase
+		g.Day = tt.day
+		sheet := s.NightSheet(g)
+		if sheet.First != tt.first {
+			t.Errorf("%s of day %d gave first %v, want %v", tt.phase, tt.day, sheet.First, tt.first)
+		}
+		hasWasherwoman := strings.Contains(strings.Join(stepIds(sheet), " "), "washerwoman")
+		if hasWasherwoman != tt.first {
+			t.Errorf("%s of day %d: washerwoman woken %v, want %v", tt.phase, tt.day, hasWasherwoman, tt.first)
+		}
+	}
+}
+
+func TestNightSheetSeats(t *testing.T) {
+	s, g := nightSheetGame(t)
+	if err := g.Kill(1); err != nil {
+		t.Fatal(err)
+	}
+	sheet := s.OtherNightSheet(g)
+	steps := make(map[string]NightStep)
+	for _, st := range sheet.Steps {
+		steps[st.Id] = st
+	}
+	if _, found := steps["empath"]; found {
+		t.Error("the dead Empath is woken")
+	}
+	if _, found := steps["drunk"]; found {
+		t.Error("the Drunk is woken as the Drunk")
+	}
+	if st, found := steps["ravenkeeper"]; !found || len(st.Seats) != 1 || st.Seats[0] != 2 {
+		t.Errorf("the Drunk believing they are the Ravenkeeper got %+v", st)
+	}
+	imp := steps["imp"]
+	if strings.Contains(imp.Reminder, reminderMarker) {
+		t.Errorf("reminder still holds the marker: %q", imp.Reminder)
+	}
+	if !strings.HasPrefix(imp.Reminder, "The Imp (Eve) chooses a player. If the Imp") {
+		t.Errorf("imp reminder is %q", imp.Reminder)
+	}
+
+	if err := g.Kill(2); err != nil {
+		t.Fatal(err)
+	}
+	sheet = s.OtherNightSheet(g)
+	if !strings.Contains(strings.Join(stepIds(sheet), " "), "ravenkeeper") {
+		t.Error("a dead Ravenkeeper is not woken")
+	}
+}
+
+func TestStripReminderMarker(t *testing.T) {
+	tests := map[string]string{
+		"The Poisoner chooses a player. :reminder:":         "The Poisoner chooses a player.",
+		"Choose a player. :reminder: If they are the Demon": "Choose a player. If they are the Demon",
+		":reminder:":                       "",
+		"No marker at all.":                "No marker at all.",
+		"Two :reminder::reminder: markers": "Two markers",
+	}
+	for in, want := range tests {
+		if got := stripReminderMarker(in); got != want {
+			t.Errorf("stripReminderMarker(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
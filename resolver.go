@@ -0,0 +1,217 @@
+package botc
+
+import "slices"
+
+type CharacterSource interface {
+	Lookup(id string) (*Role, bool)
+	Ids() []string
+}
+
+func (r *Roster) Lookup(id string) (*Role, bool) {
+	role, found := r.CharacterIndex[CanonicalId(id)]
+	return role, found && role != nil
+}
+
+func (r *Roster) Ids() []string {
+	ids := make([]string, len(r.Characters))
+	for i, c := range r.Characters {
+		ids[i] = c.Id
+	}
+	return ids
+}
+
+// Lookup and Ids make a resolved script a source of its own characters.
+func (s *Script) Lookup(id string) (*Role, bool) {
+	role, found := s.Index[CanonicalId(id)]
+	return role, found && role != nil
+}
+
+func (s *Script) Ids() []string {
+	ids := make([]string, 0, len(s.Index))
+	for id, role := range s.Index {
+		if role != nil {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// JinxWith finds the jinx between two characters on the script, using the
+// official table when either is not on it.
+func (s *Script) JinxWith(a string, b string) (string, bool) {
+	ra, foundA := s.Lookup(a)
+	rb, foundB := s.Lookup(b)
+	if foundA && foundB {
+		return ra.JinxWith(rb)
+	}
+	return OfficialJinx(a, b)
+}
+
+func (r *Roster) Suggest(id string, max int) []string {
+	return SuggestIds(id, r.Ids(), max)
+}
+
+type namedSource struct {
+	name   string
+	source CharacterSource
+}
+
+// Resolver looks characters up in several sources; sources added first take
+// precedence over those added later.
+type Resolver struct {
+	sources []namedSource
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		sources: make([]namedSource, 0),
+	}
+}
+
+func DefaultResolver() *Resolver {
+	official := OfficialRoster()
+	return NewResolver().Add("official", &official)
+}
+
+func (r *Resolver) Add(name string, source CharacterSource) *Resolver {
+	r.sources = append(r.sources, namedSource{name: name, source: source})
+	return r
+}
+
+func (r *Resolver) Sources() []string {
+	names := make([]string, len(r.sources))
+	for i, s := range r.sources {
+		names[i] = s.name
+	}
+	return names
+}
+
+func (r *Resolver) Resolve(id string) (*Role, string, bool) {
+	for _, s := range r.sources {
+		if role, found := s.source.Lookup(id); found {
+			return role, s.name, true
+		}
+	}
+	return nil, "", false
+}
+
+// Lookup and Ids let a resolver stand in wherever a single source is
+// expected.
+func (r *Resolver) Lookup(id string) (*Role, bool) {
+	role, _, found := r.Resolve(id)
+	return role, found
+}
+
+func (r *Resolver) Ids() []string {
+	ids := make([]string, 0)
+	for _, s := range r.sources {
+		for _, id := range s.source.Ids() {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func (r *Resolver) Suggest(id string, max int) []string {
+	known := make([]string, 0)
+	for _, s := range r.sources {
+		known = append(known, s.source.Ids()...)
+	}
+	return SuggestIds(id, known, max)
+}
+
+// definedIn lists the sources that define id, in order of precedence.
+func (r *Resolver) definedIn(id string) []string {
+	names := make([]string, 0)
+	for _, s := range r.sources {
+		if _, found := s.source.Lookup(id); found {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
+
+type ShadowedCharacter struct {
+	Id       string   `json:"id"`
+	Source   string   `json:"source"`
+	Shadowed []string `json:"shadowed"`
+}
+
+// Shadowed lists every id defined by more than one source, with the source
+// that wins and those it hides.
+func (r *Resolver) Shadowed() []ShadowedCharacter {
+	ids := r.Ids()
+	slices.Sort(ids)
+	shadowed := make([]ShadowedCharacter, 0)
+	for _, id := range ids {
+		names := r.definedIn(id)
+		if len(names) > 1 {
+			shadowed = append(shadowed, ShadowedCharacter{
+				Id:       id,
+				Source:   names[0],
+				Shadowed: names[1:],
+			})
+		}
+	}
+	return shadowed
+}
+
+const ScriptSource = "script"
+
+const maxSuggestions = 3
+
+type Resolution struct {
+	Missing     []string            `json:"missing"`
+	Suggestions map[string][]string `json:"suggestions"`
+	Shadowed    []ShadowedCharacter `json:"shadowed"`
+}
+
+// PopulateIndexFrom fills the script's index from its own custom characters
+// and the resolver, recording in Sources where each character came from.
+// Custom characters on the script shadow any source.
+func (s *Script) PopulateIndexFrom(r *Resolver) Resolution {
+	res := Resolution{
+		Missing:     make([]string, 0),
+		Suggestions: make(map[string][]string),
+		Shadowed:    make([]ShadowedCharacter, 0),
+	}
+	if s.Index == nil {
+		s.Index = make(map[string]*Role)
+	}
+	s.Sources = make(map[string]string)
+	for _, o := range s.OriginalCharacterIds {
+		role, source, found := r.Resolve(o)
+		if !found {
+			res.Missing = append(res.Missing, o)
+			if suggestions := r.Suggest(o, maxSuggestions); len(suggestions) > 0 {
+				res.Suggestions[o] = suggestions
+			}
+			continue
+		}
+		s.Index[o] = role
+		s.Sources[o] = source
+		if names := r.definedIn(o); len(names) > 1 {
+			res.Shadowed = append(res.Shadowed, ShadowedCharacter{
+				Id:       o,
+				Source:   source,
+				Shadowed: names[1:],
+			})
+		}
+	}
+	for i := range s.CustomCharacters {
+		c := &s.CustomCharacters[i]
+		s.Index[c.Id] = c
+		s.Sources[c.Id] = ScriptSource
+		if names := r.definedIn(c.Id); len(names) > 0 {
+			res.Shadowed = append(res.Shadowed, ShadowedCharacter{
+				Id:       c.Id,
+				Source:   ScriptSource,
+				Shadowed: names,
+			})
+		}
+	}
+	return res
+}
@@ -0,0 +1,67 @@
+package botc
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestPopulateIndexFromSources(t *testing.T) {
+	homebrew, err := DecodeRoster([]byte(`[{"id":"chef","name":"Chef","team":"townsfolk","ability":"Homebrew chef."},{"id":"oni","name":"Oni","team":"demon","ability":"x"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	official := OfficialRoster()
+	r := NewResolver().Add("homebrew", &homebrew).Add("official", &official)
+
+	s, err := DecodeScript([]byte(`["chef","oni","washerwoman","nobody",{"id":"empath","name":"Empath","team":"townsfolk","ability":"Custom empath."}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := s.PopulateIndexFrom(r)
+
+	wantSources := map[string]string{
+		"chef":        "homebrew",
+		"oni":         "homebrew",
+		"washerwoman": "official",
+		"empath":      ScriptSource,
+	}
+	if !reflect.DeepEqual(s.Sources, wantSources) {
+		t.Errorf("sources %v, want %v", s.Sources, wantSources)
+	}
+	if got := s.Index["chef"].Ability; got != "Homebrew chef." {
+		t.Errorf("chef resolved to %q, want the homebrew copy", got)
+	}
+	if got := s.Index["empath"].Ability; got != "Custom empath." {
+		t.Errorf("empath resolved to %q, want the script's copy", got)
+	}
+	if !slices.Equal(res.Missing, []string{"nobody"}) {
+		t.Errorf("missing %v", res.Missing)
+	}
+
+	wantShadowed := []ShadowedCharacter{
+		{Id: "chef", Source: "homebrew", Shadowed: []string{"official"}},
+		{Id: "empath", Source: ScriptSource, Shadowed: []string{"official"}},
+	}
+	if !reflect.DeepEqual(res.Shadowed, wantShadowed) {
+		t.Errorf("shadowed %+v, want %+v", res.Shadowed, wantShadowed)
+	}
+	if got := r.Shadowed(); len(got) != 1 || got[0].Id != "chef" {
+		t.Errorf("resolver shadowed %+v, want only chef", got)
+	}
+}
+
+func TestPopulateIndexMissing(t *testing.T) {
+	s, err := DecodeScript([]byte(`["washerwoman","washerwomn"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	official := OfficialRoster()
+	missing := s.PopulateIndex(official)
+	if !slices.Equal(missing, []string{"washerwomn"}) {
+		t.Errorf("missing %v", missing)
+	}
+	if s.Sources["washerwoman"] != official.Name {
+		t.Errorf("sources %v", s.Sources)
+	}
+}
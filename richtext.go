@@ -0,0 +1,294 @@
+package botc
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type SpanKind string
+
+const (
+	PlainSpan     SpanKind = "plain"
+	EmphasisSpan  SpanKind = "emphasis"
+	ReminderSpan  SpanKind = "reminder"
+	CharacterSpan SpanKind = "character"
+)
+
+// reminderMarker stands for a reminder token in night reminder text.
+const reminderMarker = ":reminder:"
+
+// Span is a run of text of one kind. Ref is the id of the character a
+// character span names, or of the character a reminder token belongs to.
+type Span struct {
+	Kind SpanKind `json:"kind"`
+	Text string   `json:"text"`
+	Ref  string   `json:"ref,omitempty"`
+}
+
+type RichText []Span
+
+var emphasised = regexp.MustCompile(`\*([^*\n]+)\*|` + regexp.QuoteMeta(reminderMarker))
+
+type RichTextParser struct {
+	owner *Role
+	names map[string]string
+	re    *regexp.Regexp
+}
+
+// NewRichTextParser parses text written for owner, whose reminder tokens are
+// recognised wherever the text refers to them, and links the names of the
+// characters in source. Either may be nil.
+func NewRichTextParser(owner *Role, source CharacterSource) *RichTextParser {
+	p := &RichTextParser{
+		owner: owner,
+		names: make(map[string]string),
+	}
+	if owner != nil {
+		p.names[owner.Name] = owner.Id
+	}
+	if source != nil {
+		for _, id := range source.Ids() {
+			if r, found := source.Lookup(id); found && r.Name != "" {
+				p.names[r.Name] = r.Id
+			}
+		}
+	}
+	if len(p.names) > 0 {
+		names := make([]string, 0, len(p.names))
+		for name := range p.names {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+		// longer names first so "Evil Twin" wins over "Twin"
+		slices.SortFunc(names, func(a, b string) int {
+			return len(b) - len(a)
+		})
+		p.re = regexp.MustCompile(`\b(?:` + strings.Join(names, "|") + `)\b`)
+	}
+	return p
+}
+
+func ParseRichText(text string) RichText {
+	return NewRichTextParser(nil, nil).Parse(text)
+}
+
+func (p *RichTextParser) Parse(text string) RichText {
+	text = html.UnescapeString(text)
+	spans := make(RichText, 0)
+	last := 0
+	for _, m := range emphasised.FindAllStringSubmatchIndex(text, -1) {
+		spans = p.appendPlain(spans, text[last:m[0]])
+		if m[2] == -1 {
+			spans = append(spans, p.reminder(""))
+		} else {
+			inner := text[m[2]:m[3]]
+			if token, ok := p.token(inner); ok {
+				spans = append(spans, p.reminder(token))
+			} else {
+				spans = append(spans, Span{Kind: EmphasisSpan, Text: inner})
+			}
+		}
+		last = m[1]
+	}
+	return p.appendPlain(spans, text[last:])
+}
+
+// token finds the owner's reminder token that emphasised text refers to,
+// matching without regard to case since tokens are often shouted.
+func (p *RichTextParser) token(text string) (string, bool) {
+	if p.owner == nil {
+		return "", false
+	}
+	for _, t := range slices.Concat(p.owner.ReminderTokens, p.owner.GlobalReminders) {
+		if strings.EqualFold(t, text) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// reminder makes a reminder span; the bare marker names the owner's token
+// when it only has the one.
+func (p *RichTextParser) reminder(token string) Span {
+	s := Span{Kind: ReminderSpan, Text: token}
+	if p.owner == nil {
+		return s
+	}
+	s.Ref = p.owner.Id
+	if token == "" && len(p.owner.ReminderTokens) == 1 {
+		s.Text = p.owner.ReminderTokens[0]
+	}
+	return s
+}
+
+func (p *RichTextParser) appendPlain(spans RichText, text string) RichText {
+	if text == "" {
+		return spans
+	}
+	last := 0
+	if p.re != nil {
+		for _, m := range p.re.FindAllStringIndex(text, -1) {
+			spans = appendPlainSpan(spans, text[last:m[0]])
+			name := text[m[0]:m[1]]
+			spans = append(spans, Span{Kind: CharacterSpan, Text: name, Ref: p.names[name]})
+			last = m[1]
+		}
+	}
+	return appendPlainSpan(spans, text[last:])
+}
+
+func appendPlainSpan(spans RichText, text string) RichText {
+	if text == "" {
+		return spans
+	}
+	if n := len(spans); n > 0 && spans[n-1].Kind == PlainSpan {
+		spans[n-1].Text += text
+		return spans
+	}
+	return append(spans, Span{Kind: PlainSpan, Text: text})
+}
+
+func (r *Role) RichAbility(source CharacterSource) RichText {
+	return NewRichTextParser(r, source).Parse(r.Ability)
+}
+
+func (r *Role) RichFirstNightReminder(source CharacterSource) RichText {
+	return NewRichTextParser(r, source).Parse(r.FirstNightReminder)
+}
+
+func (r *Role) RichOtherNightReminder(source CharacterSource) RichText {
+	return NewRichTextParser(r, source).Parse(r.OtherNightReminder)
+}
+
+func (m *ScriptMeta) RichBootlegger(source CharacterSource) []RichText {
+	p := NewRichTextParser(nil, source)
+	rules := make([]RichText, len(m.Bootlegger))
+	for i, b := range m.Bootlegger {
+		rules[i] = p.Parse(b)
+	}
+	return rules
+}
+
+func (s Span) display() string {
+	if s.Kind == ReminderSpan && s.Text == "" {
+		return "reminder"
+	}
+	return s.Text
+}
+
+// String is the text without any markup.
+func (t RichText) String() string {
+	var b strings.Builder
+	for _, s := range t {
+		b.WriteString(s.display())
+	}
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+)
+
+func (t RichText) Markdown() string {
+	var b strings.Builder
+	for _, s := range t {
+		text := markdownEscaper.Replace(s.display())
+		switch s.Kind {
+		case EmphasisSpan:
+			b.WriteString("**" + text + "**")
+		case ReminderSpan:
+			b.WriteString(codeSpan(s.display()))
+		case CharacterSpan:
+			b.WriteString("[" + text + "](" + wikiUrl(s.Text) + ")")
+		default:
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+// codeSpan wraps text in a run of backticks longer than any inside it,
+// padding it when it starts or ends with one.
+func codeSpan(text string) string {
+	longest, run := 0, 0
+	for _, c := range text {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+func (t RichText) HTML() string {
+	var b strings.Builder
+	for _, s := range t {
+		text := html.EscapeString(s.display())
+		switch s.Kind {
+		case EmphasisSpan:
+			b.WriteString("<strong>" + text + "</strong>")
+		case ReminderSpan:
+			b.WriteString(`<span class="reminder">` + text + "</span>")
+		case CharacterSpan:
+			b.WriteString(`<a class="character" href="` + html.EscapeString(wikiUrl(s.Text)) + `">` + text + "</a>")
+		default:
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiReminder  = "\x1b[1;33m"
+	ansiCharacter = "\x1b[4;36m"
+)
+
+// ANSI renders the text for a terminal. Control characters are dropped from
+// the text so a script cannot smuggle in escape sequences of its own.
+func (t RichText) ANSI() string {
+	var b strings.Builder
+	for _, s := range t {
+		text := stripControl(s.display())
+		switch s.Kind {
+		case EmphasisSpan:
+			b.WriteString(ansiBold + text + ansiReset)
+		case ReminderSpan:
+			b.WriteString(ansiReminder + text + ansiReset)
+		case CharacterSpan:
+			b.WriteString(ansiCharacter + text + ansiReset)
+		default:
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+// stripControl removes the C0 and C1 control characters, ESC and DEL among
+// them, keeping newlines and tabs.
+func stripControl(text string) string {
+	return strings.Map(func(c rune) rune {
+		if c == '\n' || c == '\t' {
+			return c
+		}
+		if c < 0x20 || (c >= 0x7f && c <= 0x9f) {
+			return -1
+		}
+		return c
+	}, text)
+}
@@ -0,0 +1,41 @@
+package botc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRichTextANSIStripsControl(t *testing.T) {
+	owner := &Role{Id: "x", Name: "X", ReminderTokens: []string{"Bad\x1b]0;pwned\x07"}}
+	text := NewRichTextParser(owner, nil).Parse("Wake \x1b[2Jthe player\x9b31m. :reminder: *bold\x1b[8m*\nNext line\tok")
+	got := text.ANSI()
+	for _, c := range []string{"\x1b[2J", "\x1b]0", "\x07", "\x9b", "\x1b[8m"} {
+		if strings.Contains(got, c) {
+			t.Errorf("%q survived in %q", c, got)
+		}
+	}
+	if !strings.Contains(got, "\nNext line\tok") {
+		t.Errorf("newline or tab lost in %q", got)
+	}
+	if !strings.Contains(got, ansiReminder+"Bad]0;pwned"+ansiReset) {
+		t.Errorf("reminder not rendered in %q", got)
+	}
+}
+
+func TestRichTextMarkdownReminder(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"Poisoned", "`Poisoned`"},
+		{"Not `this`", "`` Not `this` ``"},
+		{"a``b", "```a``b```"},
+	}
+	for _, tt := range tests {
+		owner := &Role{Id: "x", Name: "X", ReminderTokens: []string{tt.token}}
+		got := NewRichTextParser(owner, nil).Parse(":reminder:").Markdown()
+		if got != tt.want {
+			t.Errorf("reminder %q rendered %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
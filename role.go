@@ -1,9 +1,9 @@
 package botc
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
-	"reflect"
 	"slices"
 	"strings"
 
@@ -50,27 +50,78 @@ const (
 	None   Alignment = "None"
 )
 
+var alignments = []Alignment{Good, Evil, Either, None}
+
 func (a Alignment) String() string {
 	return string(a)
 }
 
 type Role struct {
-	Id                 string            `json:"id"`
-	Name               string            `json:"name"`
-	Edition            Edition           `json:"edition"`
-	ImageUrls          []string          `json:"image"`
-	Team               RoleType          `json:"team"`
-	Ability            string            `json:"ability"`
-	FirstNightOrder    int               `json:"firstNight"`
-	FirstNightReminder string            `json:"firstNightReminder"`
-	OtherNightOrder    int               `json:"otherNight"`
-	OtherNightReminder string            `json:"otherNightReminder"`
-	GlobalReminders    []string          `json:"remindersGlobal"`
-	ReminderTokens     []string          `json:"reminders"`
-	AltersSetup        bool              `json:"setup"`
-	Flavour            string            `json:"flavor"`
-	Special            []Special         `json:"special"`
-	Jinxes             map[string]string `json:"jinxes"`
+	Id                 string         `json:"id"`
+	Name               string         `json:"name"`
+	Edition            Edition        `json:"edition"`
+	ImageUrls          []string       `json:"image"`
+	Team               RoleType       `json:"team"`
+	Ability            string         `json:"ability"`
+	FirstNightOrder    float64        `json:"firstNight"`
+	FirstNightReminder string         `json:"firstNightReminder"`
+	OtherNightOrder    float64        `json:"otherNight"`
+	OtherNightReminder string         `json:"otherNightReminder"`
+	GlobalReminders    []string       `json:"remindersGlobal"`
+	ReminderTokens     []string       `json:"reminders"`
+	AltersSetup        bool           `json:"setup"`
+	Flavour            string         `json:"flavor"`
+	Special            []Special      `json:"special"`
+	Jinxes             Jinxes         `json:"jinxes"`
+	Extra              map[string]any `json:"-"`
+	source             *roleSource
+}
+
+// roleSource keeps just enough of the decoded object to write it back out
+// the way it came in.
+type roleSource struct {
+	present     uint32
+	order       []string
+	id          string
+	name        string
+	image       string
+	imageString bool
+}
+
+func (s *roleSource) mark(k string) {
+	if i := slices.Index(roleKeys, k); i != -1 {
+		s.present |= 1 << i
+	}
+}
+
+func newRoleSource(m map[string]any) *roleSource {
+	s := &roleSource{}
+	for k := range m {
+		s.mark(k)
+	}
+	s.id, _ = m["id"].(string)
+	s.name, _ = m["name"].(string)
+	s.image, s.imageString = m["image"].(string)
+	return s
+}
+
+var roleKeys = []string{
+	"id",
+	"name",
+	"edition",
+	"image",
+	"team",
+	"ability",
+	"firstNight",
+	"firstNightReminder",
+	"otherNight",
+	"otherNightReminder",
+	"remindersGlobal",
+	"reminders",
+	"setup",
+	"flavor",
+	"special",
+	"jinxes",
 }
 
 func (r *Role) GetName() string {
@@ -95,6 +146,9 @@ func (r *Role) Alignment() Alignment {
 }
 
 func (r *Role) ImageUrl(a Alignment) string {
+	if len(r.ImageUrls) == 0 {
+		return ""
+	}
 	d := r.Alignment()
 	// short-circuit: only select if more than 1 image or the requested isn't our default
 	if len(r.ImageUrls) > 1 && a != d {
@@ -106,7 +160,9 @@ func (r *Role) ImageUrl(a Alignment) string {
 			case Good:
 				return r.ImageUrls[1]
 			case Evil:
-				return r.ImageUrls[2]
+				if len(r.ImageUrls) > 2 {
+					return r.ImageUrls[2]
+				}
 			}
 		// if we're Good or Evil and in the switch, the requested alignment isn't our default
 		case Good, Evil:
@@ -121,111 +177,245 @@ func (r *Role) ImageUrl(a Alignment) string {
 }
 
 func (r *Role) AbilityText() string {
-	pos := strings.Index(r.Ability, "[")
-	return r.Ability[:pos-1]
+	return r.ParsedAbility().Text
 }
 
 func (r *Role) Setup() string {
-	pos := strings.Index(r.Ability, "[")
-	return r.Ability[pos+1 : len(r.Ability)-1]
+	return r.ParsedAbility().Setup
 }
 
+func (r *Role) HasSpecial(name string) bool {
+	return slices.ContainsFunc(r.Special, func(s Special) bool {
+		return s.Name == name
+	})
+}
+
+// JinxWith finds the jinx between r and o, whichever of them declares it,
+// falling back to the official jinx table.
 func (r *Role) JinxWith(o *Role) (string, bool) {
-	text, found := r.Jinxes[o.Id]
-	return text, found
+	if text, found := r.declaredJinx(o.Id); found {
+		return text, true
+	}
+	if text, found := o.declaredJinx(r.Id); found {
+		return text, true
+	}
+	return OfficialJinx(r.Id, o.Id)
+}
+
+func (r *Role) declaredJinx(id string) (string, bool) {
+	return r.Jinxes.Get(id)
 }
 
-func (r *Role) GetFirstNightOrder() int {
+func (r *Role) GetFirstNightOrder() float64 {
 	return r.FirstNightOrder
 }
 
-func (r *Role) GetOtherNightsOrder() int {
+func (r *Role) GetOtherNightsOrder() float64 {
 	return r.OtherNightOrder
 }
 
 func (r *Role) Wiki() string {
+	return wikiUrl(r.Name)
+}
+
+func wikiUrl(name string) string {
 	return fmt.Sprintf(
 		"https://wiki.bloodontheclocktower.com/%s",
-		url.PathEscape(strings.ReplaceAll(r.Name, " ", "_")),
+		url.PathEscape(strings.ReplaceAll(name, " ", "_")),
 	)
 }
 
+// MarshalJSON takes a value so that roles held by value, as in a script's
+// custom characters or a map of roles, encode the same way as pointers.
+func (r Role) MarshalJSON() ([]byte, error) {
+	e := newObjectEncoder()
+	var order []string
+	if r.source != nil {
+		order = r.source.order
+	}
+	e.fields(order, func(emit func(k string, v any)) {
+		r.encodeFields(emit)
+		for _, k := range extraKeys(r.Extra, roleKeys) {
+			emit(k, r.Extra[k])
+		}
+	})
+	return e.bytes()
+}
+
 func (r *Role) ToMap() map[string]any {
 	m := make(map[string]any)
-	rt := reflect.TypeOf(r)
-	rv := reflect.ValueOf(r)
-	for i := 0; i < rt.NumField(); i++ {
-		f := rt.Field(i)
-		t := f.Tag.Get("json")
-		n := strings.Split(t, ",")[0]
-		v := rv.Field(i)
-		switch n {
-		case "special":
-			sm := make([]map[string]any, len(r.Special))
-			for i, s := range r.Special {
+	for k, v := range r.Extra {
+		m[k] = v
+	}
+	r.encodeFields(func(k string, v any) {
+		switch vt := v.(type) {
+		case []Special:
+			sm := make([]map[string]any, len(vt))
+			for i, s := range vt {
 				sm[i] = s.ToMap()
 			}
-			smv := reflect.ValueOf(sm)
-			m[n] = smv.Interface()
-		case "jinxes":
-			js := make([]map[string]string, len(r.Jinxes))
-			i := 0
-			for id, reason := range r.Jinxes {
-				j := make(map[string]string)
-				j["id"] = id
-				j["reason"] = reason
-				js[i] = j
-				i++
-			}
-			m[n] = js
+			m[k] = sm
+		case Jinxes:
+			m[k] = jinxesToMaps(vt)
 		default:
-			m[n] = v.Interface()
+			m[k] = v
 		}
-	}
+	})
 	return m
 }
 
+// encodeFields emits the schema properties of the role in schema order,
+// leaving out those that were absent when decoded and still hold their
+// default. MarshalJSON puts them back in the order they were decoded in.
+func (r *Role) encodeFields(emit func(k string, v any)) {
+	emit("id", r.sourceId())
+	emit("name", r.sourceName())
+	if r.source != nil && r.source.imageString && len(r.ImageUrls) == 1 && r.ImageUrls[0] == r.source.image {
+		emit("image", r.source.image)
+	} else if r.has("image") || len(r.ImageUrls) > 0 {
+		emit("image", r.ImageUrls)
+	}
+	emit("team", r.Team)
+	if r.has("edition") || r.Edition != "" {
+		emit("edition", r.Edition)
+	}
+	emit("ability", r.Ability)
+	if r.has("flavor") || r.Flavour != "" {
+		emit("flavor", r.Flavour)
+	}
+	if r.has("firstNight") || r.FirstNightOrder != -1 {
+		emit("firstNight", r.FirstNightOrder)
+	}
+	if r.has("firstNightReminder") || r.FirstNightReminder != "" {
+		emit("firstNightReminder", r.FirstNightReminder)
+	}
+	if r.has("otherNight") || r.OtherNightOrder != -1 {
+		emit("otherNight", r.OtherNightOrder)
+	}
+	if r.has("otherNightReminder") || r.OtherNightReminder != "" {
+		emit("otherNightReminder", r.OtherNightReminder)
+	}
+	if r.has("reminders") || len(r.ReminderTokens) > 0 {
+		emit("reminders", r.ReminderTokens)
+	}
+	if r.has("remindersGlobal") || len(r.GlobalReminders) > 0 {
+		emit("remindersGlobal", r.GlobalReminders)
+	}
+	if r.has("setup") || r.AltersSetup {
+		emit("setup", r.AltersSetup)
+	}
+	if r.has("jinxes") || len(r.Jinxes) > 0 {
+		js := r.Jinxes
+		if js == nil {
+			js = Jinxes{}
+		}
+		emit("jinxes", js)
+	}
+	if r.has("special") || len(r.Special) > 0 {
+		emit("special", r.Special)
+	}
+}
+
+// keepOrder records the order of the members of the object r was decoded
+// from, and of its specials, which decoding through a map loses.
+func (r *Role) keepOrder(raw []byte) {
+	if r.source == nil {
+		return
+	}
+	r.source.order, _ = scanObject(raw, roleKeys)
+	if len(r.Special) == 0 {
+		return
+	}
+	var members map[string]json.RawMessage
+	var specials []json.RawMessage
+	if json.Unmarshal(raw, &members) != nil || json.Unmarshal(members["special"], &specials) != nil {
+		return
+	}
+	if len(specials) == len(r.Special) {
+		for i, sp := range specials {
+			r.Special[i].order, _ = scanObject(sp, specialKeys)
+		}
+	}
+}
+
+func (r *Role) has(k string) bool {
+	i := slices.Index(roleKeys, k)
+	return r.source != nil && i != -1 && r.source.present&(1<<i) != 0
+}
+
+func (r *Role) sourceId() string {
+	if r.source != nil && CanonicalId(r.source.id) == r.Id {
+		return r.source.id
+	}
+	return r.Id
+}
+
+func (r *Role) sourceName() string {
+	if r.source != nil && strings.TrimSuffix(r.source.name, " RAH") == r.Name {
+		return r.source.name
+	}
+	return r.Name
+}
+
 func NewRole(m map[string]any) (Role, error) {
+	r, errs := decodeRole(m)
+	if len(errs) > 0 {
+		return r, errs[0].err
+	}
+	return r, nil
+}
+
+type fieldError struct {
+	key string
+	err error
+}
+
+func decodeRole(m map[string]any) (Role, []*fieldError) {
 	var r Role
+	errs := make([]*fieldError, 0)
+	fail := func(key string, err error) {
+		errs = append(errs, &fieldError{key: key, err: err})
+	}
+
 	id, err := extractRoleId(m)
 	if err != nil {
-		return r, err
+		fail("id", err)
 	}
 	r.Id = id
 
 	name, err := extractRequiredString("name", m)
 	if err != nil {
-		return r, err
+		fail("name", err)
 	}
 	r.Name = strings.TrimSuffix(name, " RAH")
 
 	edition, err := extractRoleEdition(m)
 	if err != nil {
-		return r, err
+		fail("edition", err)
 	}
 	r.Edition = edition
 
 	image, err := extractRoleImageUrls(m)
 	if err != nil {
-		return r, err
+		fail("image", err)
 	}
 	r.ImageUrls = image
 
 	team, err := extractRoleTeam(m)
 	if err != nil {
-		return r, err
+		fail("team", err)
 	}
 	r.Team = team
 
 	ability, err := extractRequiredString("ability", m)
 	if err != nil {
-		return r, err
+		fail("ability", err)
 	}
 	r.Ability = ability
 
-	firstNight, found, err := extractInt("firstNight", m)
+	firstNight, found, err := extractFloat("firstNight", m)
 	if err != nil {
-		return r, err
+		fail("firstNight", err)
 	}
 	if !found {
 		r.FirstNightOrder = -1
@@ -235,13 +425,13 @@ func NewRole(m map[string]any) (Role, error) {
 
 	firstNightReminder, _, err := extractString("firstNightReminder", m)
 	if err != nil {
-		return r, err
+		fail("firstNightReminder", err)
 	}
 	r.FirstNightReminder = firstNightReminder
 
-	otherNight, found, err := extractInt("otherNight", m)
+	otherNight, found, err := extractFloat("otherNight", m)
 	if err != nil {
-		return r, err
+		fail("otherNight", err)
 	}
 	if !found {
 		r.OtherNightOrder = -1
@@ -251,53 +441,50 @@ func NewRole(m map[string]any) (Role, error) {
 
 	otherNightReminder, _, err := extractString("otherNightReminder", m)
 	if err != nil {
-		return r, err
+		fail("otherNightReminder", err)
 	}
 	r.OtherNightReminder = otherNightReminder
 
 	globalReminders, _, err := extractStringSlice("remindersGlobal", m)
 	if err != nil {
-		return r, err
+		fail("remindersGlobal", err)
 	}
 	r.GlobalReminders = globalReminders
 
 	reminders, _, err := extractStringSlice("reminders", m)
 	if err != nil {
-		return r, err
+		fail("reminders", err)
 	}
 	r.ReminderTokens = reminders
 
 	alters, _, err := extractBool("setup", m)
 	if err != nil {
-		return r, err
+		fail("setup", err)
 	}
 	r.AltersSetup = alters
 
 	flavour, _, err := extractString("flavor", m)
 	if err != nil {
-		return r, err
+		fail("flavor", err)
 	}
 	r.Flavour = flavour
 
 	specials, err := extractSpecial(m)
 	if err != nil {
-		return r, err
+		fail("special", err)
 	}
 	r.Special = specials
 
 	jinxes, err := extractJinxes(m)
 	if err != nil {
-		return r, err
+		fail("jinxes", err)
 	}
 	r.Jinxes = jinxes
 
-	return r, nil
-}
+	r.Extra = extractExtra(m, roleKeys)
+	r.source = newRoleSource(m)
 
-func normaliseId(id string) string {
-	return strings.ToLower(
-		strings.ReplaceAll(id, "_", ""),
-	)
+	return r, errs
 }
 
 func extractRoleId(m map[string]any) (string, error) {
@@ -306,32 +493,33 @@ func extractRoleId(m map[string]any) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	extracted = strings.TrimSuffix(extracted, "_rah")
-	return extracted, nil
+	return CanonicalId(extracted), nil
 }
 
 func extractRoleEdition(m map[string]any) (Edition, error) {
 	key := "edition"
-	ed, found, err := extractString(key, m)
-	edition := Edition(ed)
+	ed, _, err := extractString(key, m)
 	if err != nil {
-		return edition, err
-	}
-	if !found || slices.Contains(EditionOrder, edition) {
-		return edition, nil
-	} else {
-		return Edition(""), NewIllegalValueForEnumError(key, edition, EditionOrder)
+		return Edition(""), err
 	}
+	// the schema allows any edition id, not just the official ones
+	return Edition(ed), nil
 }
 
 func extractRoleImageUrls(m map[string]any) ([]string, error) {
 	key := "image"
-	urls, ok, err := extractStringSlice(key, m)
-	if !ok {
+	var urls []string
+	switch v := m[key].(type) {
+	case nil:
 		return []string{}, nil
-	}
-	if err != nil {
-		return urls, err
+	case string:
+		urls = []string{v}
+	default:
+		values, _, err := extractStringSlice(key, m)
+		if err != nil {
+			return []string{}, err
+		}
+		urls = values
 	}
 	for _, u := range urls {
 		_, err := url.Parse(u)
@@ -2,7 +2,7 @@ package botc
 
 import (
 	"encoding/json"
-	"log"
+	"strconv"
 )
 
 type Roster struct {
@@ -11,49 +11,116 @@ type Roster struct {
 	Almanac        string `json:"almanac"`
 	Characters     []*Role
 	CharacterIndex map[string]*Role
+	meta           ScriptMeta
+	references     []string
+	items          []itemRef
 }
 
 func (r *Roster) UnmarshalJSON(b []byte) error {
+	return r.decode(b, false)
+}
+
+func DecodeRoster(b []byte) (Roster, error) {
+	var r Roster
+	err := r.decode(b, true)
+	return r, err
+}
+
+func (r *Roster) decode(b []byte, collect bool) error {
 	r.CharacterIndex = make(map[string]*Role)
-	var items []any
+	var items []json.RawMessage
 	err := json.Unmarshal(b, &items)
 	if err != nil {
 		return err
 	}
-	for _, i := range items {
-		switch I := i.(type) {
-		case map[string]any:
-			switch I["id"] {
-			case "_meta":
-				r.Author = I["author"].(string)
-				r.Name = I["name"].(string)
-				r.Almanac = I["almanac"].(string)
-			default:
-				role, err := NewRole(I)
-				if err != nil {
-					log.Printf("%v", role)
+	errs := make([]*DecodeError, 0)
+	report := func(id string, path string, err error) error {
+		if !collect {
+			return err
+		}
+		errs = append(errs, NewDecodeError(id, path, err))
+		return nil
+	}
+	for n, item := range items {
+		ptr := pointerJoin("", strconv.Itoa(n))
+		var i any
+		if err := json.Unmarshal(item, &i); err != nil {
+			return err
+		}
+		I, ok := i.(map[string]any)
+		if !ok {
+			// official ids carry no definition for a roster to hold
+			if _, isId := i.(string); isId {
+				r.addReference(i)
+				continue
+			}
+			if err := report("", ptr, NewConversionError("item", i)); err != nil {
+				return err
+			}
+			continue
+		}
+		switch {
+		case I["id"] == "_meta":
+			meta, metaErrs := decodeScriptMeta(I)
+			meta.order, _ = scanObject(item, metaKeys)
+			for _, e := range metaErrs {
+				if err := report("_meta", pointerJoin(ptr, e.key), e.err); err != nil {
 					return err
 				}
-				r.Characters = append(r.Characters, &role)
-				r.CharacterIndex[role.Id] = &role
 			}
+			r.meta = meta
+			r.Author = meta.Author
+			r.Name = meta.Name
+			r.Almanac = meta.Almanac
+			r.items = append(r.items, itemRef{kind: metaItem})
+		case isOfficialReference(I):
+			if _, err := extractRequiredString("id", I); err != nil {
+				if err := report("", pointerJoin(ptr, "id"), err); err != nil {
+					return err
+				}
+				continue
+			}
+			r.addReference(compactItem(item))
+		default:
+			role, roleErrs := decodeRole(I)
+			if len(roleErrs) > 0 {
+				for _, e := range roleErrs {
+					if err := report(role.Id, pointerJoin(ptr, e.key), e.err); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			role.keepOrder(item)
+			r.items = append(r.items, itemRef{kind: characterItem, index: len(r.Characters)})
+			r.Characters = append(r.Characters, &role)
+			r.CharacterIndex[role.Id] = &role
 		}
 	}
+	if len(errs) > 0 {
+		return NewDecodeErrors(errs)
+	}
 	return nil
 }
 
+func (r *Roster) addReference(raw any) {
+	r.items = append(r.items, itemRef{kind: referenceItem, index: len(r.references), raw: raw})
+	r.references = append(r.references, referenceId(raw))
+}
+
 func (r *Roster) MarshalJSON() ([]byte, error) {
-	items := make([]any, len(r.Characters)+1)
-	meta := make(map[string]string)
-	meta["author"] = r.Author
-	meta["name"] = r.Name
-	meta["almanac"] = r.Almanac
-	items[0] = meta
+	meta := r.meta
+	meta.Author = r.Author
+	meta.Name = r.Name
+	meta.Almanac = r.Almanac
+	hasMeta := len(r.items) == 0 || r.meta.source != nil || r.Name != ""
+	chars := make([]any, len(r.Characters))
 	for i, c := range r.Characters {
-		items[i+1] = c.ToMap()
+		chars[i] = c
 	}
+	items := encodeItems(r.items, metaDocument{&meta}, hasMeta, r.references, chars)
 	var bytes []byte
-	bytes, err := json.Marshal(items)
+	bytes, err := encodeArray(items)
 	if err != nil {
 		return bytes, err
 	}
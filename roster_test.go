@@ -0,0 +1,38 @@
+package botc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRosterRequiresReferenceId(t *testing.T) {
+	data, err := os.ReadFile("asset/jinxes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Roster
+	if err := r.UnmarshalJSON(data); err == nil {
+		t.Error("decoded the jinx table as a roster")
+	}
+
+	doc := []byte(`[{"id":"washerwoman"},{"image":"https://example.com/x.png"}]`)
+	_, err = DecodeRoster(doc)
+	var decodeErrs *DecodeErrors
+	if !errors.As(err, &decodeErrs) {
+		t.Fatalf("got %v, want decode errors", err)
+	}
+	if errs := decodeErrs.Errors(); len(errs) != 1 || errs[0].Path() != "/1/id" {
+		t.Errorf("got %v, want one error at /1/id", err)
+	}
+
+	d := NewRosterDecoder(bytes.NewReader(doc))
+	if _, err := d.Next(); !errors.As(err, &decodeErrs) {
+		t.Errorf("streaming got %v, want decode errors", err)
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("streaming got %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,91 @@
+package botc
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type itemKind int
+
+const (
+	metaItem itemKind = iota
+	characterItem
+	referenceItem
+)
+
+// itemRef records where a top-level item sat in the decoded document, so that
+// encoding can put it back in the same place and in the same form.
+type itemRef struct {
+	kind  itemKind
+	index int
+	raw   any
+}
+
+func referenceId(raw any) string {
+	switch r := raw.(type) {
+	case string:
+		return CanonicalId(r)
+	case json.RawMessage:
+		var m map[string]any
+		_ = json.Unmarshal(r, &m)
+		id, _ := m["id"].(string)
+		return CanonicalId(id)
+	default:
+		return ""
+	}
+}
+
+// compactItem keeps an item exactly as written, less the whitespace.
+func compactItem(item json.RawMessage) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, item); err != nil {
+		return item
+	}
+	return buf.Bytes()
+}
+
+// encodeItems lays out meta, references and characters following the decoded
+// item order; anything added after decoding is appended after the rest.
+func encodeItems(order []itemRef, meta any, hasMeta bool, refs []string, chars []any) []any {
+	items := make([]any, 0, len(order)+1)
+	metaDone := false
+	refDone := make([]bool, len(refs))
+	charDone := make([]bool, len(chars))
+	for _, it := range order {
+		switch it.kind {
+		case metaItem:
+			if hasMeta && !metaDone {
+				items = append(items, meta)
+				metaDone = true
+			}
+		case referenceItem:
+			if it.index < len(refs) && !refDone[it.index] {
+				if referenceId(it.raw) == refs[it.index] {
+					items = append(items, it.raw)
+				} else {
+					items = append(items, refs[it.index])
+				}
+				refDone[it.index] = true
+			}
+		case characterItem:
+			if it.index < len(chars) && !charDone[it.index] {
+				items = append(items, chars[it.index])
+				charDone[it.index] = true
+			}
+		}
+	}
+	if hasMeta && !metaDone {
+		items = append([]any{meta}, items...)
+	}
+	for i, id := range refs {
+		if !refDone[i] {
+			items = append(items, id)
+		}
+	}
+	for i, c := range chars {
+		if !charDone[i] {
+			items = append(items, c)
+		}
+	}
+	return items
+}
@@ -0,0 +1,107 @@
+package botc
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+type roundTripper interface {
+	json.Marshaler
+	json.Unmarshaler
+}
+
+func TestRoundTrip(t *testing.T) {
+	kinds := []struct {
+		name string
+		new  func() roundTripper
+	}{
+		{"script", func() roundTripper { return &Script{} }},
+		{"roster", func() roundTripper { return &Roster{} }},
+	}
+	for _, f := range scriptAssets(t) {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want bytes.Buffer
+		if err := json.Compact(&want, data); err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range kinds {
+			t.Run(k.name+"/"+f, func(t *testing.T) {
+				doc := k.new()
+				if err := doc.UnmarshalJSON(data); err != nil {
+					t.Fatal(err)
+				}
+				out, err := doc.MarshalJSON()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(out, want.Bytes()) {
+					t.Fatalf("round trip changed the document from byte %d:\n got %s\nwant %s", diffAt(out, want.Bytes()), excerpt(out, want.Bytes()), excerpt(want.Bytes(), out))
+				}
+			})
+		}
+	}
+}
+
+func TestRoundTripExact(t *testing.T) {
+	docs := []string{
+		`["b",{"custom":1,"id":"_meta","name":"x"},{"id":"a","name":"A","team":"townsfolk","ability":"y","firstNight":0,"reminders":[],"zzz":true},{"id":"c"}]`,
+		`[{"id":"a","name":"A","image":"https://example.com/a.png","team":"minion","ability":"y","setup":false,"jinxes":[{"id":"z","reason":"r"},{"id":"b","reason":"s"}]}]`,
+		`[{"name":"Mine & Yours","id":"_meta","author":"<me>"},{"zzz":1,"team":"demon","id":"A_b","edition":"x","name":"A","aaa":2,"firstNight":12.5,"ability":"y & z","special":[{"name":"grimoire","type":"signal","zz":1}]},{"image":"x","id":"c"}]`,
+	}
+	for _, doc := range docs {
+		for _, v := range []roundTripper{&Script{}, &Roster{}} {
+			if err := v.UnmarshalJSON([]byte(doc)); err != nil {
+				t.Fatal(err)
+			}
+			out, err := v.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != doc {
+				t.Errorf("%T round trip\n got %s\nwant %s", v, out, doc)
+			}
+		}
+	}
+}
+
+func TestRosterDecoderKeepsOrder(t *testing.T) {
+	doc := `[{"zzz":1,"team":"demon","id":"a","name":"A","ability":"y","special":[{"name":"grimoire","type":"signal"}]},{"ability":"y","name":"B","team":"demon","id":"b","colour":"red"}]`
+	want := []string{
+		`{"zzz":1,"team":"demon","id":"a","name":"A","ability":"y","special":[{"name":"grimoire","type":"signal"}]}`,
+		`{"ability":"y","name":"B","team":"demon","id":"b","colour":"red"}`,
+	}
+	d := NewRosterDecoder(strings.NewReader(doc))
+	for _, w := range want {
+		r, err := d.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := r.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != w {
+			t.Errorf("\n got %s\nwant %s", out, w)
+		}
+	}
+}
+
+func diffAt(a, b []byte) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// excerpt shows a around where it first differs from b.
+func excerpt(a, b []byte) []byte {
+	n := diffAt(a, b)
+	return a[max(0, n-40):min(len(a), n+80)]
+}
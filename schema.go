@@ -0,0 +1,442 @@
+package botc
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+//go:embed asset/script-schema.json
+var scriptSchemaData []byte
+
+var (
+	scriptSchemaOnce sync.Once
+	scriptSchema     map[string]any
+	scriptSchemaErr  error
+)
+
+func loadScriptSchema() (map[string]any, error) {
+	scriptSchemaOnce.Do(func() {
+		scriptSchemaErr = json.Unmarshal(scriptSchemaData, &scriptSchema)
+	})
+	return scriptSchema, scriptSchemaErr
+}
+
+type SchemaViolation struct {
+	Pointer string `json:"pointer"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s: %s", pointer, v.Message)
+}
+
+type SchemaValidationError struct {
+	violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	msgs := make([]string, len(e.violations))
+	for i, v := range e.violations {
+		msgs[i] = v.String()
+	}
+	return fmt.Sprintf("script failed schema validation with %d violation(s): %s", len(e.violations), strings.Join(msgs, "; "))
+}
+
+func (e *SchemaValidationError) Violations() []SchemaViolation {
+	return e.violations
+}
+
+func NewSchemaValidationError(violations []SchemaViolation) *SchemaValidationError {
+	return &SchemaValidationError{
+		violations: violations,
+	}
+}
+
+func ValidateScriptJSON(data []byte) error {
+	schema, err := loadScriptSchema()
+	if err != nil {
+		return fmt.Errorf("invalid bundled schema: %w", err)
+	}
+	var doc any
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+	violations := validateSchema(schema, doc, "")
+	if len(violations) > 0 {
+		return NewSchemaValidationError(violations)
+	}
+	return nil
+}
+
+func (s *Script) Validate() error {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return ValidateScriptJSON(data)
+}
+
+func pointerJoin(ptr string, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return ptr + "/" + token
+}
+
+func violation(ptr, keyword, format string, args ...any) SchemaViolation {
+	return SchemaViolation{
+		Pointer: ptr,
+		Keyword: keyword,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func jsonType(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if x == math.Trunc(x) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func typeMatches(want string, v any) bool {
+	got := jsonType(v)
+	return got == want || (want == "number" && got == "integer")
+}
+
+func validateSchema(schema map[string]any, v any, ptr string) []SchemaViolation {
+	violations := make([]SchemaViolation, 0)
+
+	if t, ok := schema["type"]; ok {
+		var types []string
+		switch tt := t.(type) {
+		case string:
+			types = []string{tt}
+		case []any:
+			types, _ = convertStringSlice(tt)
+		}
+		if !slices.ContainsFunc(types, func(want string) bool { return typeMatches(want, v) }) {
+			// nothing below applies to a value of the wrong type
+			return append(violations, violation(ptr, "type", "expected %s, got %s", strings.Join(types, " or "), jsonType(v)))
+		}
+	}
+
+	if e, ok := schema["enum"].([]any); ok {
+		if !slices.ContainsFunc(e, func(x any) bool { return jsonEqual(x, v) }) {
+			violations = append(violations, violation(ptr, "enum", "value %v must be one of %v", v, e))
+		}
+	}
+	if c, ok := schema["const"]; ok {
+		if !jsonEqual(c, v) {
+			violations = append(violations, violation(ptr, "const", "value %v must be %v", v, c))
+		}
+	}
+
+	switch x := v.(type) {
+	case string:
+		violations = append(violations, validateString(schema, x, ptr)...)
+	case float64:
+		violations = append(violations, validateNumber(schema, x, ptr)...)
+	case []any:
+		violations = append(violations, validateArray(schema, x, ptr)...)
+	case map[string]any:
+		violations = append(violations, validateObject(schema, x, ptr)...)
+	}
+
+	if all, ok := schema["allOf"].([]any); ok {
+		for _, sub := range all {
+			if subSchema, ok := sub.(map[string]any); ok {
+				violations = append(violations, validateSchema(subSchema, v, ptr)...)
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		violations = append(violations, validateAnyOf(anyOf, v, ptr)...)
+	}
+	if one, ok := schema["oneOf"].([]any); ok {
+		violations = append(violations, validateOneOf(one, v, ptr)...)
+	}
+	if not, ok := schema["not"].(map[string]any); ok {
+		if len(validateSchema(not, v, ptr)) == 0 {
+			violations = append(violations, violation(ptr, "not", "value must not match schema"))
+		}
+	}
+
+	return violations
+}
+
+func validateString(schema map[string]any, s string, ptr string) []SchemaViolation {
+	violations := make([]SchemaViolation, 0)
+	length := utf8.RuneCountInString(s)
+	if max, ok := schema["maxLength"].(float64); ok && length > int(max) {
+		violations = append(violations, violation(ptr, "maxLength", "length %d exceeds maximum of %d", length, int(max)))
+	}
+	if min, ok := schema["minLength"].(float64); ok && length < int(min) {
+		violations = append(violations, violation(ptr, "minLength", "length %d is less than minimum of %d", length, int(min)))
+	}
+	if format, ok := schema["format"].(string); ok && format == "uri" {
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" {
+			violations = append(violations, violation(ptr, "format", "%q is not a valid absolute URI", s))
+		}
+	}
+	return violations
+}
+
+func validateNumber(schema map[string]any, n float64, ptr string) []SchemaViolation {
+	violations := make([]SchemaViolation, 0)
+	if max, ok := schema["maximum"].(float64); ok && n > max {
+		violations = append(violations, violation(ptr, "maximum", "%v exceeds maximum of %v", n, max))
+	}
+	if min, ok := schema["minimum"].(float64); ok && n < min {
+		violations = append(violations, violation(ptr, "minimum", "%v is less than minimum of %v", n, min))
+	}
+	return violations
+}
+
+func validateArray(schema map[string]any, a []any, ptr string) []SchemaViolation {
+	violations := make([]SchemaViolation, 0)
+	if max, ok := schema["maxItems"].(float64); ok && len(a) > int(max) {
+		violations = append(violations, violation(ptr, "maxItems", "%d items exceeds maximum of %d", len(a), int(max)))
+	}
+	if min, ok := schema["minItems"].(float64); ok && len(a) < int(min) {
+		violations = append(violations, violation(ptr, "minItems", "%d items is less than minimum of %d", len(a), int(min)))
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		for i, item := range a {
+			violations = append(violations, validateSchema(items, item, pointerJoin(ptr, strconv.Itoa(i)))...)
+		}
+	}
+	return violations
+}
+
+func validateObject(schema map[string]any, o map[string]any, ptr string) []SchemaViolation {
+	violations := make([]SchemaViolation, 0)
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			key, _ := r.(string)
+			if _, found := o[key]; !found {
+				violations = append(violations, violation(ptr, "required", "required property %q missing", key))
+			}
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	// sort keys so violations are reported in a stable order
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if prop, ok := properties[k].(map[string]any); ok {
+			violations = append(violations, validateSchema(prop, o[k], pointerJoin(ptr, k))...)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				violations = append(violations, violation(pointerJoin(ptr, k), "additionalProperties", "property %q is not allowed", k))
+			}
+		case map[string]any:
+			violations = append(violations, validateSchema(additional, o[k], pointerJoin(ptr, k))...)
+		}
+	}
+	return violations
+}
+
+func validateAnyOf(schemas []any, v any, ptr string) []SchemaViolation {
+	best := make([]SchemaViolation, 0)
+	bestScore := -1
+	for _, sub := range schemas {
+		subSchema, ok := sub.(map[string]any)
+		if !ok {
+			continue
+		}
+		vs := validateSchema(subSchema, v, ptr)
+		if len(vs) == 0 {
+			return []SchemaViolation{}
+		}
+		score := branchScore(subSchema, vs, ptr)
+		if bestScore == -1 || score < bestScore {
+			best, bestScore = vs, score
+		}
+	}
+	return best
+}
+
+func validateOneOf(schemas []any, v any, ptr string) []SchemaViolation {
+	if branch, ok := discriminate(schemas, v); ok {
+		return validateSchema(branch, v, ptr)
+	}
+	matched := 0
+	best := make([]SchemaViolation, 0)
+	bestScore := -1
+	for _, sub := range schemas {
+		subSchema, ok := sub.(map[string]any)
+		if !ok {
+			continue
+		}
+		vs := validateSchema(subSchema, v, ptr)
+		if len(vs) == 0 {
+			matched++
+			continue
+		}
+		score := branchScore(subSchema, vs, ptr)
+		if bestScore == -1 || score < bestScore {
+			best, bestScore = vs, score
+		}
+	}
+	switch {
+	case matched == 1:
+		return []SchemaViolation{}
+	case matched > 1:
+		return []SchemaViolation{violation(ptr, "oneOf", "value matches %d schemas, must match exactly one", matched)}
+	default:
+		return best
+	}
+}
+
+// discriminate picks the branch a script item was meant to match from what
+// it is rather than from how badly it fails each branch: a string is an
+// official id, an object with id _meta is the meta, an object with a name,
+// team or ability is a character, and any other object is the deprecated
+// official reference. ok is false unless exactly one branch is of that kind.
+func discriminate(schemas []any, v any) (map[string]any, bool) {
+	kind := schemaItemKind(v)
+	if kind == "" {
+		return nil, false
+	}
+	var found map[string]any
+	for _, sub := range schemas {
+		subSchema, ok := sub.(map[string]any)
+		if !ok || schemaBranchKind(subSchema) != kind {
+			continue
+		}
+		if found != nil {
+			return nil, false
+		}
+		found = subSchema
+	}
+	return found, found != nil
+}
+
+func schemaItemKind(v any) string {
+	switch x := v.(type) {
+	case string:
+		return "id"
+	case map[string]any:
+		switch {
+		case x["id"] == "_meta":
+			return "meta"
+		case !isOfficialReference(x):
+			return "character"
+		default:
+			return "reference"
+		}
+	default:
+		return ""
+	}
+}
+
+func schemaBranchKind(schema map[string]any) string {
+	switch schema["type"] {
+	case "string":
+		return "id"
+	case "object":
+		properties, _ := schema["properties"].(map[string]any)
+		id, _ := properties["id"].(map[string]any)
+		if e, ok := id["enum"].([]any); ok && slices.Contains(e, any("_meta")) {
+			return "meta"
+		}
+		if c, ok := id["const"]; ok && c == "_meta" {
+			return "meta"
+		}
+		if required, ok := schema["required"].([]any); ok && slices.Contains(required, any("ability")) {
+			return "character"
+		}
+		return "reference"
+	default:
+		return ""
+	}
+}
+
+// branchScore ranks the failures of a oneOf/anyOf branch so that the branch
+// the value was most likely meant to match is the one reported: wrong types
+// and discriminating enum values at the top level weigh far more than a
+// single bad field further down.
+func branchScore(schema map[string]any, vs []SchemaViolation, ptr string) int {
+	score := 0
+	if deprecated, _ := schema["deprecated"].(bool); deprecated {
+		score += 500
+	}
+	for _, v := range vs {
+		direct := strings.HasPrefix(v.Pointer, ptr+"/") && !strings.Contains(v.Pointer[len(ptr)+1:], "/")
+		switch {
+		case v.Pointer == ptr && v.Keyword == "type":
+			score += 1000
+		case direct && (v.Keyword == "enum" || v.Keyword == "const"):
+			score += 100
+		default:
+			score++
+		}
+	}
+	return score
+}
+
+func jsonEqual(a, b any) bool {
+	switch x := a.(type) {
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			yv, found := y[k]
+			if !found || !jsonEqual(xv, yv) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
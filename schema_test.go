@@ -0,0 +1,111 @@
+package botc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func violationsOf(t *testing.T, doc string) []SchemaViolation {
+	t.Helper()
+	err := ValidateScriptJSON([]byte(doc))
+	if err == nil {
+		return nil
+	}
+	var verr *SchemaValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	return verr.Violations()
+}
+
+func TestValidateScriptJSON(t *testing.T) {
+	long := strings.Repeat("n", 34)
+	tests := []struct {
+		name string
+		doc  string
+		want []SchemaViolation
+	}{
+		{
+			name: "valid",
+			doc:  `[{"id":"_meta","name":"x"},"a","b","c",{"id":"d","name":"D","team":"townsfolk","ability":"y"}]`,
+		},
+		{
+			name: "long name",
+			doc:  `[{"id":"_meta","name":"x"},"a","b","c",{"id":"d","name":"` + long + `","team":"townsfolk","ability":"y"}]`,
+			want: []SchemaViolation{{Pointer: "/4/name", Keyword: "maxLength"}},
+		},
+		{
+			name: "bad team",
+			doc:  `[{"id":"_meta","name":"x"},"a","b","c",{"id":"d","name":"D","team":"bogus","ability":"y"}]`,
+			want: []SchemaViolation{{Pointer: "/4/team", Keyword: "enum"}},
+		},
+		{
+			name: "extra property",
+			doc:  `[{"id":"_meta","name":"x"},"a","b","c",{"id":"d","name":"D","team":"townsfolk","ability":"y","colour":"red"}]`,
+			want: []SchemaViolation{{Pointer: "/4/colour", Keyword: "additionalProperties"}},
+		},
+		{
+			name: "too few items",
+			doc:  `[{"id":"_meta","name":"x"},"a","b"]`,
+			want: []SchemaViolation{{Pointer: "", Keyword: "minItems"}},
+		},
+		{
+			name: "every violation of a character",
+			doc:  `[{"id":"_meta","name":"x"},"a","b","c",{"id":"x","name":"` + long + `","team":"bogus","ability":"y"}]`,
+			want: []SchemaViolation{
+				{Pointer: "/4/name", Keyword: "maxLength"},
+				{Pointer: "/4/team", Keyword: "enum"},
+			},
+		},
+		{
+			name: "meta",
+			doc:  `[{"id":"_meta","name":"x","hideTitle":"yes"},"a","b","c","d"]`,
+			want: []SchemaViolation{{Pointer: "/0/hideTitle", Keyword: "type"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationsOf(t, tt.doc)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for _, w := range tt.want {
+				if !slices.ContainsFunc(got, func(v SchemaViolation) bool {
+					return v.Pointer == w.Pointer && v.Keyword == w.Keyword
+				}) {
+					t.Errorf("missing %s %s in %v", w.Pointer, w.Keyword, got)
+				}
+			}
+		})
+	}
+}
+
+// scriptAssets lists the script and roster documents in asset/, leaving out
+// the schema and the jinx table.
+func scriptAssets(t testing.TB) []string {
+	t.Helper()
+	files, err := filepath.Glob("asset/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return slices.DeleteFunc(files, func(f string) bool {
+		base := filepath.Base(f)
+		return base == "script-schema.json" || base == "jinxes.json"
+	})
+}
+
+func TestValidateScriptAssets(t *testing.T) {
+	for _, f := range scriptAssets(t) {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vs := violationsOf(t, string(data)); len(vs) > 0 {
+			t.Errorf("%s: %v", f, vs)
+		}
+	}
+}
@@ -1,41 +1,122 @@
 package botc
 
 import (
-	"bytes"
 	"compress/gzip"
-	"encoding/base64"
 	"encoding/json"
-	"fmt"
-	"log"
-	"net/url"
-	"slices"
-	"strings"
+	"strconv"
 )
 
 type NightOrdered interface {
 	GetName() string
-	GetFirstNightOrder() int
-	GetOtherNightsOrder() int
+	GetFirstNightOrder() float64
+	GetOtherNightsOrder() float64
 }
 
 type ScriptMeta struct {
-	Id         string   `json:"id"`
-	Name       string   `json:"name"`
-	Author     string   `json:"author"`
-	Logo       string   `json:"logo"`
-	HideTitle  bool     `json:"hideTitle"`
-	Background string   `json:"background"`
-	Almanac    string   `json:"almanac"`
-	Bootlegger []string `json:"bootlegger"`
-	FirstNight []string `json:"firstNight"`
-	OtherNight []string `json:"otherNight"`
+	Id         string         `json:"id"`
+	Name       string         `json:"name"`
+	Author     string         `json:"author"`
+	Logo       string         `json:"logo"`
+	HideTitle  bool           `json:"hideTitle"`
+	Background string         `json:"background"`
+	Almanac    string         `json:"almanac"`
+	Bootlegger []string       `json:"bootlegger"`
+	FirstNight []string       `json:"firstNight"`
+	OtherNight []string       `json:"otherNight"`
+	Extra      map[string]any `json:"-"`
+	source     map[string]any
+	order      []string
+}
+
+var metaKeys = []string{
+	"id",
+	"name",
+	"author",
+	"logo",
+	"hideTitle",
+	"background",
+	"almanac",
+	"bootlegger",
+	"firstNight",
+	"otherNight",
+}
+
+func (m *ScriptMeta) has(k string) bool {
+	_, found := m.source[k]
+	return found
+}
+
+func (m *ScriptMeta) ToMap() map[string]any {
+	out := make(map[string]any)
+	for k, v := range m.Extra {
+		out[k] = v
+	}
+	m.encodeFields(func(k string, v any) {
+		out[k] = v
+	})
+	return out
+}
+
+// encodeFields emits the schema properties of the meta in schema order,
+// leaving out those that were absent when decoded and still hold their
+// default.
+func (m *ScriptMeta) encodeFields(emit func(k string, v any)) {
+	emit("id", "_meta")
+	emit("name", m.Name)
+	if m.has("author") || m.Author != "" {
+		emit("author", m.Author)
+	}
+	if m.has("logo") || m.Logo != "" {
+		emit("logo", m.Logo)
+	}
+	if m.has("hideTitle") || m.HideTitle {
+		emit("hideTitle", m.HideTitle)
+	}
+	if m.has("background") || m.Background != "" {
+		emit("background", m.Background)
+	}
+	if m.has("almanac") || m.Almanac != "" {
+		emit("almanac", m.Almanac)
+	}
+	lists := []struct {
+		key   string
+		value []string
+	}{
+		{"bootlegger", m.Bootlegger},
+		{"firstNight", m.FirstNight},
+		{"otherNight", m.OtherNight},
+	}
+	for _, l := range lists {
+		if m.has(l.key) || len(l.value) > 0 {
+			emit(l.key, l.value)
+		}
+	}
+}
+
+// metaDocument encodes a meta item in the order it was decoded in.
+type metaDocument struct {
+	*ScriptMeta
+}
+
+func (d metaDocument) MarshalJSON() ([]byte, error) {
+	m := d.ScriptMeta
+	e := newObjectEncoder()
+	e.fields(m.order, func(emit func(k string, v any)) {
+		m.encodeFields(emit)
+		for _, k := range extraKeys(m.Extra, metaKeys) {
+			emit(k, m.Extra[k])
+		}
+	})
+	return e.bytes()
 }
 
 type Script struct {
-	Meta                 ScriptMeta       `json:"meta"`
-	CustomCharacters     []Role           `json:"custom"`
-	OriginalCharacterIds []string         `json:"original"`
-	Index                map[string]*Role `json:"index"`
+	Meta                 ScriptMeta        `json:"meta"`
+	CustomCharacters     []Role            `json:"custom"`
+	OriginalCharacterIds []string          `json:"original"`
+	Index                map[string]*Role  `json:"index"`
+	Sources              map[string]string `json:"sources"`
+	items                []itemRef
 }
 
 func (s *Script) Author() string {
@@ -46,19 +127,20 @@ func (s *Script) Author() string {
 	}
 }
 
+// PopulateIndex fills the script's index from a single roster and returns
+// the ids it could not find.
 func (s *Script) PopulateIndex(r Roster) []string {
-	notFound := make([]string, 0)
-	for _, o := range s.OriginalCharacterIds {
-		orig, ok := r.CharacterIndex[o]
-		if !ok {
-			notFound = append(notFound, o)
-		}
-		s.Index[o] = orig
-	}
-	for _, c := range s.CustomCharacters {
-		s.Index[c.Id] = &c
+	return s.PopulateIndexFromRoster(r).Missing
+}
+
+// PopulateIndexFromRoster is PopulateIndex with the full resolution,
+// including the nearest matches for each missing id.
+func (s *Script) PopulateIndexFromRoster(r Roster) Resolution {
+	name := r.Name
+	if name == "" {
+		name = "roster"
 	}
-	return notFound
+	return s.PopulateIndexFrom(NewResolver().Add(name, &r))
 }
 
 func (s *Script) GetCharacter(id string) Role {
@@ -66,149 +148,175 @@ func (s *Script) GetCharacter(id string) Role {
 }
 
 func (s *Script) FirstNight() []NightOrdered {
-	order := make([]NightOrdered, 0)
-	for e := range firstNight {
-		order = append(order, e)
-	}
-	for _, role := range s.Index {
-		if role.FirstNightOrder != -1 {
-			order = append(order, role)
-		}
-	}
-	slices.SortFunc(order, func(a, b NightOrdered) int {
-		return a.GetFirstNightOrder() - b.GetFirstNightOrder()
-	})
-	return order
+	return NewNightOrderBuilder(s).FirstNight().Order
 }
 
 func (s *Script) OtherNights() []NightOrdered {
-	order := make([]NightOrdered, 0)
-	for e := range otherNights {
-		order = append(order, e)
-	}
-	for _, role := range s.Index {
-		if role.OtherNightOrder != -1 {
-			order = append(order, role)
-		}
-	}
-	slices.SortFunc(order, func(a, b NightOrdered) int {
-		return a.GetOtherNightsOrder() - b.GetOtherNightsOrder()
-	})
-	return order
+	return NewNightOrderBuilder(s).OtherNights().Order
 }
 
-func (s *Script) OfficialToolUrl() string {
-	var buf bytes.Buffer
-	gz := gzip.NewWriter(&buf)
-	jdata, err := json.Marshal(s)
+// UnknownNightOrderIds lists the ids named in the script's own night orders
+// that are neither a night event nor a character on the script.
+func (s *Script) UnknownNightOrderIds() ([]string, []string) {
+	b := NewNightOrderBuilder(s)
+	return b.FirstNight().Unknown, b.OtherNights().Unknown
+}
+
+func (s *Script) OfficialToolUrl() (string, error) {
+	jdata, err := s.MarshalJSON()
 	if err != nil {
-		log.Fatalln(err)
-	}
-	if _, err := gz.Write(jdata); err != nil {
-		log.Fatalln(err)
+		return "", err
 	}
-	gz.Close()
-	enc := base64.StdEncoding.EncodeToString(buf.Bytes())
-	esc := url.QueryEscape(enc)
-	scriptUrl := fmt.Sprintf("https://script.bloodontheclocktower.com/?script=%s", esc)
-	return scriptUrl
+	return shareUrl(jdata, gzip.DefaultCompression)
 }
 
 func (s *Script) UnmarshalJSON(data []byte) error {
-	raw := make([]any, 0)
-	err := json.Unmarshal(data, &raw)
+	return s.decode(data, false)
+}
+
+func DecodeScript(data []byte) (Script, error) {
+	var s Script
+	err := s.decode(data, true)
+	return s, err
+}
+
+func (s *Script) decode(data []byte, collect bool) error {
+	items := make([]json.RawMessage, 0)
+	err := json.Unmarshal(data, &items)
 	if err != nil {
 		return err
 	}
-	for _, v := range raw {
+	errs := make([]*DecodeError, 0)
+	report := func(id string, path string, err error) error {
+		if !collect {
+			return err
+		}
+		errs = append(errs, NewDecodeError(id, path, err))
+		return nil
+	}
+	for n, item := range items {
+		ptr := pointerJoin("", strconv.Itoa(n))
+		var v any
+		if err := json.Unmarshal(item, &v); err != nil {
+			return err
+		}
 		switch vt := v.(type) {
 		case string:
-			s.OriginalCharacterIds = append(s.OriginalCharacterIds, strings.ReplaceAll(vt, "_", ""))
+			s.items = append(s.items, itemRef{kind: referenceItem, index: len(s.OriginalCharacterIds), raw: vt})
+			s.OriginalCharacterIds = append(s.OriginalCharacterIds, referenceId(vt))
 		case map[string]any:
 			if vt["id"] == "_meta" {
-				s.Meta.Id = vt["id"].(string)
-				s.Meta.Name = checkNilString(vt["name"])
-				s.Meta.Author = checkNilString(vt["author"])
-				s.Meta.Almanac = checkNilString(vt["almanac"])
-				s.Meta.Bootlegger = checkNilStringS(vt["bootlegger"])
-				s.Meta.FirstNight = checkNilStringS(vt["firstNight"])
-				s.Meta.OtherNight = checkNilStringS(vt["otherNight"])
-			} else {
-				if len(vt) == 1 {
-					s.OriginalCharacterIds = append(s.OriginalCharacterIds, normaliseId(vt["id"].(string)))
-				} else {
-					role, err := NewRole(vt)
-					if err != nil {
+				meta, metaErrs := decodeScriptMeta(vt)
+				meta.order, _ = scanObject(item, metaKeys)
+				for _, e := range metaErrs {
+					if err := report("_meta", pointerJoin(ptr, e.key), e.err); err != nil {
 						return err
 					}
-					s.CustomCharacters = append(s.CustomCharacters, role)
 				}
+				s.Meta = meta
+				s.items = append(s.items, itemRef{kind: metaItem})
+			} else if isOfficialReference(vt) {
+				_, err := extractRequiredString("id", vt)
+				if err != nil {
+					if err := report("", pointerJoin(ptr, "id"), err); err != nil {
+						return err
+					}
+					continue
+				}
+				ref := compactItem(item)
+				s.items = append(s.items, itemRef{kind: referenceItem, index: len(s.OriginalCharacterIds), raw: ref})
+				s.OriginalCharacterIds = append(s.OriginalCharacterIds, referenceId(ref))
+			} else {
+				role, roleErrs := decodeRole(vt)
+				if len(roleErrs) > 0 {
+					for _, e := range roleErrs {
+						if err := report(role.Id, pointerJoin(ptr, e.key), e.err); err != nil {
+							return err
+						}
+					}
+					continue
+				}
+				role.keepOrder(item)
+				s.items = append(s.items, itemRef{kind: characterItem, index: len(s.CustomCharacters)})
+				s.CustomCharacters = append(s.CustomCharacters, role)
+			}
+		default:
+			if err := report("", ptr, NewConversionError("item", v)); err != nil {
+				return err
 			}
 		}
 	}
 	s.Index = make(map[string]*Role)
+	if len(errs) > 0 {
+		return NewDecodeErrors(errs)
+	}
 	return nil
 }
 
-func (s *Script) MarshalJSON() ([]byte, error) {
-	raw := make([]any, 0)
-	meta := make(map[string]any)
-	meta["id"] = s.Meta.Id
-	meta["name"] = s.Meta.Name
-	if s.Meta.Author != "" {
-		meta["author"] = s.Meta.Author
-	}
-	if s.Meta.Logo != "" {
-		meta["logo"] = s.Meta.Logo
+func decodeScriptMeta(m map[string]any) (ScriptMeta, []*fieldError) {
+	meta := ScriptMeta{Id: "_meta"}
+	errs := make([]*fieldError, 0)
+	strs := map[string]*string{
+		"name":       &meta.Name,
+		"author":     &meta.Author,
+		"logo":       &meta.Logo,
+		"background": &meta.Background,
+		"almanac":    &meta.Almanac,
 	}
-	meta["hideTitle"] = s.Meta.HideTitle
-	if s.Meta.Background != "" {
-		meta["background"] = s.Meta.Background
+	for _, k := range []string{"name", "author", "logo", "background", "almanac"} {
+		value, err := extractOptionalString(k, m)
+		if err != nil {
+			errs = append(errs, &fieldError{key: k, err: err})
+			continue
+		}
+		*strs[k] = value
 	}
-	if s.Meta.Almanac != "" {
-		meta["almanac"] = s.Meta.Almanac
+	hideTitle, _, err := extractBool("hideTitle", m)
+	if err != nil {
+		errs = append(errs, &fieldError{key: "hideTitle", err: err})
 	}
-	if len(s.Meta.Bootlegger) > 0 {
-		meta["bootlegger"] = s.Meta.Bootlegger
+	meta.HideTitle = hideTitle
+	lists := map[string]*[]string{
+		"bootlegger": &meta.Bootlegger,
+		"firstNight": &meta.FirstNight,
+		"otherNight": &meta.OtherNight,
 	}
-	meta["firstNight"] = s.Meta.FirstNight
-	meta["otherNight"] = s.Meta.OtherNight
-	raw = append(raw, meta)
-	for _, o := range s.OriginalCharacterIds {
-		raw = append(raw, o)
+	for _, k := range []string{"bootlegger", "firstNight", "otherNight"} {
+		value, _, err := extractStringSlice(k, m)
+		if err != nil {
+			errs = append(errs, &fieldError{key: k, err: err})
+			continue
+		}
+		*lists[k] = value
 	}
-	for _, c := range s.CustomCharacters {
-		raw = append(raw, c.ToMap())
+	meta.Extra = extractExtra(m, metaKeys)
+	meta.source = m
+	return meta, errs
+}
+
+func (s *Script) MarshalJSON() ([]byte, error) {
+	chars := make([]any, len(s.CustomCharacters))
+	for i := range s.CustomCharacters {
+		chars[i] = &s.CustomCharacters[i]
 	}
+	hasMeta := len(s.items) == 0 || s.Meta.source != nil || s.Meta.Name != ""
+	raw := encodeItems(s.items, metaDocument{&s.Meta}, hasMeta, s.OriginalCharacterIds, chars)
 	var bytes []byte
-	bytes, err := json.Marshal(raw)
+	bytes, err := encodeArray(raw)
 	if err != nil {
 		return bytes, err
 	}
 	return bytes, nil
 }
 
-func checkNilString(x any) string {
-	if x != nil {
-		return x.(string)
-	} else {
-		return ""
-	}
-}
-
-func checkNilStringS(x any) []string {
-	if x != nil {
-		return convertStringS(x)
-	} else {
-		return make([]string, 0)
-	}
-}
-
-func convertStringS(x any) []string {
-	xs := x.([]any)
-	r := make([]string, len(xs))
-	for i, s := range xs {
-		r[i] = s.(string)
+// isOfficialReference reports whether an object item refers to an official
+// character by id rather than defining a character of its own; custom
+// characters always carry a name, team and ability.
+func isOfficialReference(m map[string]any) bool {
+	for _, k := range []string{"name", "team", "ability"} {
+		if _, found := m[k]; found {
+			return false
+		}
 	}
-	return r
+	return true
 }
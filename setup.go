@@ -0,0 +1,254 @@
+package botc
+
+import (
+	"slices"
+)
+
+const (
+	MinPlayers    = 5
+	MaxPlayers    = 15
+	MaxTravellers = 5
+)
+
+type Distribution struct {
+	Townsfolk int `json:"townsfolk"`
+	Outsiders int `json:"outsiders"`
+	Minions   int `json:"minions"`
+	Demons    int `json:"demons"`
+}
+
+// baseDistributions holds the standard bag for 5 to 15 players.
+var baseDistributions = []Distribution{
+	{3, 0, 1, 1},
+	{3, 1, 1, 1},
+	{5, 0, 1, 1},
+	{5, 1, 1, 1},
+	{5, 2, 1, 1},
+	{7, 0, 2, 1},
+	{7, 1, 2, 1},
+	{7, 2, 2, 1},
+	{9, 0, 3, 1},
+	{9, 1, 3, 1},
+	{9, 2, 3, 1},
+}
+
+func BaseDistribution(players int) (Distribution, error) {
+	if players < MinPlayers || players > MaxPlayers {
+		return Distribution{}, NewPlayerCountError(players, 0)
+	}
+	return baseDistributions[players-MinPlayers], nil
+}
+
+func (d Distribution) Count(rt RoleType) int {
+	switch rt {
+	case Townsfolk:
+		return d.Townsfolk
+	case Outsider:
+		return d.Outsiders
+	case Minion:
+		return d.Minions
+	case Demon:
+		return d.Demons
+	default:
+		return 0
+	}
+}
+
+func (d Distribution) Total() int {
+	return d.Townsfolk + d.Outsiders + d.Minions + d.Demons
+}
+
+func (d *Distribution) count(rt RoleType) *int {
+	switch rt {
+	case Townsfolk:
+		return &d.Townsfolk
+	case Outsider:
+		return &d.Outsiders
+	case Minion:
+		return &d.Minions
+	case Demon:
+		return &d.Demons
+	default:
+		return nil
+	}
+}
+
+// adjust moves n characters onto team rt, taking them from team from. A team
+// never drops below zero, so -1 Outsider with none in play does nothing.
+func (d Distribution) adjust(rt RoleType, n int, from RoleType) Distribution {
+	target := d.count(rt)
+	balance := d.count(from)
+	if target == nil || balance == nil || target == balance {
+		return d
+	}
+	n = max(n, -*target)
+	n = min(n, *balance)
+	*target += n
+	*balance -= n
+	return d
+}
+
+type SetupPlan struct {
+	Players    int            `json:"players"`
+	Travellers int            `json:"travellers"`
+	Base       Distribution   `json:"base"`
+	Adjusted   []Distribution `json:"adjusted"`
+	// Required lists characters a modifier puts in the bag, such as the
+	// Huntsman's Damsel.
+	Required []string `json:"required"`
+	// Variable lists characters whose modifier leaves the amount to the
+	// Storyteller, such as the Xaan.
+	Variable []string `json:"variable"`
+	// Unresolved lists characters that alter setup in a way that cannot be
+	// expressed as a change of counts.
+	Unresolved []string `json:"unresolved"`
+}
+
+// Might reports whether the Storyteller has more than one legal bag to pick
+// from.
+func (p SetupPlan) Might() bool {
+	return len(p.Adjusted) > 1 || len(p.Variable) > 0
+}
+
+// CalculateSetup works out the bag for a game of players non-traveller
+// players plus travellers, given the characters selected for it. Added
+// characters are looked up amongst roles first and then in the official
+// catalogue to decide which team they count against.
+func CalculateSetup(players int, travellers int, roles []*Role) (SetupPlan, error) {
+	plan := SetupPlan{
+		Players:    players,
+		Travellers: travellers,
+		Adjusted:   []Distribution{},
+		Required:   []string{},
+		Variable:   []string{},
+		Unresolved: []string{},
+	}
+	if travellers < 0 || travellers > MaxTravellers {
+		return plan, NewPlayerCountError(players, travellers)
+	}
+
+	type change struct {
+		team    RoleType
+		from    RoleType
+		options []int
+	}
+	changes := make([]change, 0)
+	reduced := 0
+	for _, r := range roles {
+		a := r.ParsedAbility()
+		if !r.AltersSetup && a.Setup == "" {
+			continue
+		}
+		// changes come out of the Townsfolk, unless the character never
+		// goes in the bag, in which case they take its own place
+		from := Townsfolk
+		if !r.holdsSeat() {
+			from = r.Team
+		}
+		applied := false
+		for _, m := range a.Modifiers {
+			switch m.Effect {
+			case ReducePlayerCount:
+				reduced -= m.Options[0]
+				applied = true
+			case AdjustTeam:
+				if m.Variable {
+					plan.Variable = appendUnique(plan.Variable, r.Id)
+				} else {
+					changes = append(changes, change{team: m.Team, from: balanceFor(m.Team, from), options: m.Options})
+				}
+				applied = true
+			case AddCharacter:
+				plan.Required = appendUnique(plan.Required, m.Character)
+				if m.Variable {
+					plan.Variable = appendUnique(plan.Variable, r.Id)
+				} else if team := addedTeam(m.Character, roles); team != Townsfolk {
+					changes = append(changes, change{team: team, from: balanceFor(team, from), options: m.Options})
+				}
+				applied = true
+			}
+		}
+		if !applied {
+			plan.Unresolved = appendUnique(plan.Unresolved, r.Id)
+		}
+	}
+
+	base, err := BaseDistribution(players - reduced)
+	if err != nil {
+		return plan, NewPlayerCountError(players, travellers)
+	}
+	plan.Base = base
+
+	// modifiers are added up before any is applied, so the order characters
+	// were selected in can't stop one from cancelling another out
+	type move struct {
+		team RoleType
+		from RoleType
+	}
+	moves := make([]move, 0)
+	for _, c := range changes {
+		if m := (move{c.team, c.from}); !slices.Contains(moves, m) {
+			moves = append(moves, m)
+		}
+	}
+	totals := [][]int{make([]int, len(moves))}
+	for _, c := range changes {
+		k := slices.Index(moves, move{c.team, c.from})
+		next := make([][]int, 0, len(totals)*len(c.options))
+		for _, t := range totals {
+			for _, n := range c.options {
+				total := slices.Clone(t)
+				total[k] += n
+				if !slices.ContainsFunc(next, func(o []int) bool { return slices.Equal(o, total) }) {
+					next = append(next, total)
+				}
+			}
+		}
+		totals = next
+	}
+	outcomes := make([]Distribution, 0, len(totals))
+	for _, t := range totals {
+		adjusted := base
+		for k, m := range moves {
+			adjusted = adjusted.adjust(m.team, t[k], m.from)
+		}
+		if !slices.Contains(outcomes, adjusted) {
+			outcomes = append(outcomes, adjusted)
+		}
+	}
+	slices.SortFunc(outcomes, func(a, b Distribution) int {
+		if a.Outsiders != b.Outsiders {
+			return a.Outsiders - b.Outsiders
+		}
+		return a.Minions - b.Minions
+	})
+	plan.Adjusted = outcomes
+	return plan, nil
+}
+
+func balanceFor(team RoleType, from RoleType) RoleType {
+	if team == from && team == Townsfolk {
+		return Outsider
+	}
+	return from
+}
+
+func addedTeam(id string, roles []*Role) RoleType {
+	for _, r := range roles {
+		if r.Id == id {
+			return r.Team
+		}
+	}
+	official := OfficialRoster()
+	if r, found := official.Lookup(id); found {
+		return r.Team
+	}
+	return Townsfolk
+}
+
+func appendUnique(s []string, v string) []string {
+	if slices.Contains(s, v) {
+		return s
+	}
+	return append(s, v)
+}
@@ -0,0 +1,107 @@
+package botc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func officialRoles(t *testing.T, ids ...string) []*Role {
+	t.Helper()
+	official := OfficialRoster()
+	roles := make([]*Role, len(ids))
+	for i, id := range ids {
+		r, found := official.Lookup(id)
+		if !found {
+			t.Fatalf("no official character %q", id)
+		}
+		roles[i] = r
+	}
+	return roles
+}
+
+func TestCalculateSetup(t *testing.T) {
+	tests := []struct {
+		players    int
+		ids        []string
+		adjusted   []Distribution
+		required   []string
+		variable   []string
+		unresolved []string
+	}{
+		{8, nil, []Distribution{{5, 1, 1, 1}}, nil, nil, nil},
+		{8, []string{"baron"}, []Distribution{{3, 3, 1, 1}}, nil, nil, nil},
+		{5, []string{"baron"}, []Distribution{{1, 2, 1, 1}}, nil, nil, nil},
+		{8, []string{"godfather"}, []Distribution{{6, 0, 1, 1}, {4, 2, 1, 1}}, nil, nil, nil},
+		// no Outsiders to remove, so -1 leaves the bag alone
+		{7, []string{"godfather"}, []Distribution{{5, 0, 1, 1}, {4, 1, 1, 1}}, nil, nil, nil},
+		{8, []string{"fanggu"}, []Distribution{{4, 2, 1, 1}}, nil, nil, nil},
+		{8, []string{"vigormortis"}, []Distribution{{6, 0, 1, 1}}, nil, nil, nil},
+		{8, []string{"balloonist"}, []Distribution{{5, 1, 1, 1}, {4, 2, 1, 1}}, nil, nil, nil},
+		{8, []string{"huntsman"}, []Distribution{{4, 2, 1, 1}}, []string{"damsel"}, nil, nil},
+		// Lil' Monsta gives up the Demon's place to an extra Minion
+		{8, []string{"lilmonsta"}, []Distribution{{5, 1, 2, 0}}, nil, nil, nil},
+		{8, []string{"kazali"}, []Distribution{{5, 1, 1, 1}}, nil, []string{"kazali"}, nil},
+		{8, []string{"xaan"}, []Distribution{{5, 1, 1, 1}}, nil, []string{"xaan"}, nil},
+		{8, []string{"hermit"}, []Distribution{{6, 0, 1, 1}, {5, 1, 1, 1}}, nil, nil, nil},
+		{8, []string{"sentinel"}, []Distribution{{6, 0, 1, 1}, {5, 1, 1, 1}, {4, 2, 1, 1}}, nil, nil, nil},
+		{8, []string{"marionette"}, []Distribution{{5, 1, 1, 1}}, nil, nil, []string{"marionette"}},
+
+		{8, []string{"baron", "fanggu"}, []Distribution{{2, 4, 1, 1}}, nil, nil, nil},
+		{8, []string{"baron", "vigormortis"}, []Distribution{{4, 2, 1, 1}}, nil, nil, nil},
+		{8, []string{"baron", "lilmonsta"}, []Distribution{{3, 3, 2, 0}}, nil, nil, nil},
+		{8, []string{"huntsman", "baron"}, []Distribution{{2, 4, 1, 1}}, []string{"damsel"}, nil, nil},
+		{8, []string{"xaan", "baron"}, []Distribution{{3, 3, 1, 1}}, nil, []string{"xaan"}, nil},
+		{8, []string{"godfather", "balloonist"}, []Distribution{{6, 0, 1, 1}, {5, 1, 1, 1}, {4, 2, 1, 1}, {3, 3, 1, 1}}, nil, nil, nil},
+		{8, []string{"sentinel", "baron"}, []Distribution{{4, 2, 1, 1}, {3, 3, 1, 1}, {2, 4, 1, 1}}, nil, nil, nil},
+		{10, []string{"hermit", "vigormortis"}, []Distribution{{7, 0, 2, 1}}, nil, nil, nil},
+		// the Hermit's -1 can cancel out the Fang Gu's +1 even though it is
+		// listed first, when there are no Outsiders yet
+		{10, []string{"hermit", "fanggu", "godfather"}, []Distribution{{7, 0, 2, 1}, {6, 1, 2, 1}, {5, 2, 2, 1}}, nil, nil, nil},
+		{10, []string{"vigormortis", "baron"}, []Distribution{{6, 1, 2, 1}}, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		plan, err := CalculateSetup(tt.players, 0, officialRoles(t, tt.ids...))
+		if err != nil {
+			t.Errorf("%d %v: %v", tt.players, tt.ids, err)
+			continue
+		}
+		want := SetupPlan{
+			Players:    tt.players,
+			Base:       baseDistributions[tt.players-MinPlayers],
+			Adjusted:   tt.adjusted,
+			Required:   orEmpty(tt.required),
+			Variable:   orEmpty(tt.variable),
+			Unresolved: orEmpty(tt.unresolved),
+		}
+		if !reflect.DeepEqual(plan, want) {
+			t.Errorf("%d %v:\ngot  %+v\nwant %+v", tt.players, tt.ids, plan, want)
+		}
+		if might := len(tt.adjusted) > 1 || len(tt.variable) > 0; plan.Might() != might {
+			t.Errorf("%d %v: Might() = %v", tt.players, tt.ids, plan.Might())
+		}
+	}
+}
+
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func TestCalculateSetupPlayerCount(t *testing.T) {
+	roles := officialRoles(t, "baron")
+	for _, tt := range []struct{ players, travellers int }{
+		{MinPlayers - 1, 0},
+		{MaxPlayers + 1, 0},
+		{8, MaxTravellers + 1},
+		{8, -1},
+	} {
+		_, err := CalculateSetup(tt.players, tt.travellers, roles)
+		var countErr *PlayerCountError
+		if !errors.As(err, &countErr) {
+			t.Errorf("%d+%d: got %v, want a player count error", tt.players, tt.travellers, err)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package botc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	officialToolBase = "https://script.bloodontheclocktower.com/"
+	shareParam       = "script"
+)
+
+// maxSharedScript caps how far a shared payload is decompressed, so a
+// hostile link cannot expand without limit.
+const maxSharedScript = 8 << 20
+
+func shareUrl(data []byte, level int) (string, error) {
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return "", err
+	}
+	if _, err := gz.Write(data); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	enc := base64.StdEncoding.EncodeToString(buf.Bytes())
+	return officialToolBase + "?" + shareParam + "=" + url.QueryEscape(enc), nil
+}
+
+// ParseShareUrl loads the script in a link made by the official script tool,
+// or by OfficialToolUrl. It also accepts the bare value of the script
+// parameter, escaped or not.
+func ParseShareUrl(s string) (Script, error) {
+	var script Script
+	payload, err := sharePayload(strings.TrimSpace(s))
+	if err != nil {
+		return script, err
+	}
+	data, err := decodeSharePayload(payload)
+	if err != nil {
+		return script, err
+	}
+	err = script.UnmarshalJSON(data)
+	return script, err
+}
+
+func sharePayload(s string) (string, error) {
+	if strings.Contains(s, "://") || strings.Contains(s, "?") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", NewShareUrlError("not a url", err)
+		}
+		payload := u.Query().Get(shareParam)
+		if payload == "" {
+			return "", NewShareUrlError("no script parameter", nil)
+		}
+		return payload, nil
+	}
+	// the bare payload may still be query escaped; a literal + is base64
+	// so it is left alone
+	payload, err := url.PathUnescape(s)
+	if err != nil {
+		return "", NewShareUrlError("bad escape", err)
+	}
+	return payload, nil
+}
+
+func decodeSharePayload(payload string) ([]byte, error) {
+	// links pasted without escaping lose their + to spaces, and some tools
+	// use the url-safe alphabet or drop the padding
+	payload = strings.NewReplacer(" ", "+", "-", "+", "_", "/").Replace(payload)
+	payload = strings.TrimRight(payload, "=")
+	compressed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, NewShareUrlError("bad base64", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, NewShareUrlError("bad gzip", err)
+	}
+	defer gz.Close()
+	data, err := io.ReadAll(io.LimitReader(gz, maxSharedScript+1))
+	if err != nil {
+		return nil, NewShareUrlError("bad gzip", err)
+	}
+	if len(data) > maxSharedScript {
+		return nil, NewShareUrlError("script too large", nil)
+	}
+	return data, nil
+}
+
+type ShareLimit struct {
+	Name   string `json:"name"`
+	Length int    `json:"length"`
+}
+
+// DefaultShareLimits are the message and address lengths a shared link most
+// often has to fit in.
+var DefaultShareLimits = []ShareLimit{
+	{Name: "Discord message", Length: 2000},
+	{Name: "Legacy browser address", Length: 2083},
+	{Name: "Discord Nitro message", Length: 4000},
+	{Name: "Slack message", Length: 40000},
+}
+
+type ShareLink struct {
+	Url      string       `json:"url"`
+	Length   int          `json:"length"`
+	Within   []ShareLimit `json:"within"`
+	Exceeded []ShareLimit `json:"exceeded"`
+}
+
+func (l ShareLink) Fits(limit ShareLimit) bool {
+	return l.Length <= limit.Length
+}
+
+// CompactToolUrl makes the shortest link the official script tool loads as
+// the same script, and checks its length against limits, or against
+// DefaultShareLimits when none are given. The link is never longer than the
+// one OfficialToolUrl makes.
+func (s *Script) CompactToolUrl(limits ...ShareLimit) (ShareLink, error) {
+	var link ShareLink
+	compact, err := s.MarshalCompactJSON()
+	if err != nil {
+		return link, err
+	}
+	full, err := s.MarshalJSON()
+	if err != nil {
+		return link, err
+	}
+	// gzip does not always shrink the smaller document further, nor does
+	// the best level always win on short input, so keep the shortest
+	for _, data := range [][]byte{compact, full} {
+		for _, level := range []int{gzip.BestCompression, gzip.DefaultCompression} {
+			u, err := shareUrl(data, level)
+			if err != nil {
+				return link, err
+			}
+			if link.Url == "" || len(u) < len(link.Url) {
+				link.Url = u
+			}
+		}
+	}
+	link.Length = len(link.Url)
+	if len(limits) == 0 {
+		limits = DefaultShareLimits
+	}
+	link.Within = make([]ShareLimit, 0, len(limits))
+	link.Exceeded = make([]ShareLimit, 0)
+	for _, l := range limits {
+		if link.Fits(l) {
+			link.Within = append(link.Within, l)
+		} else {
+			link.Exceeded = append(link.Exceeded, l)
+		}
+	}
+	return link, nil
+}
+
+// MarshalCompactJSON encodes the script as the smallest equivalent document:
+// official characters, whether referenced or copied in full, become bare ids,
+// and fields holding their default are left out.
+func (s *Script) MarshalCompactJSON() ([]byte, error) {
+	official := OfficialRoster()
+	// the id each character is written under, by canonical id, so that night
+	// orders and jinxes can refer to it the same way
+	ids := make(map[string]string)
+	for _, id := range s.OriginalCharacterIds {
+		ids[id] = id
+	}
+	chars := make([]any, len(s.CustomCharacters))
+	for i := range s.CustomCharacters {
+		c := &s.CustomCharacters[i]
+		if o, found := official.CharacterIndex[CanonicalId(c.Id)]; found && c.sameAs(o) {
+			chars[i] = o.Id
+			ids[c.Id] = o.Id
+			continue
+		}
+		chars[i] = compactRole{c, ids}
+		ids[c.Id] = c.sourceId()
+	}
+	order := make([]itemRef, len(s.items))
+	for i, it := range s.items {
+		// dropping the raw form makes references encode as bare ids
+		order[i] = itemRef{kind: it.kind, index: it.index}
+	}
+	meta := compactMeta{&s.Meta, ids}
+	raw := encodeItems(order, meta, !meta.empty(), s.OriginalCharacterIds, chars)
+	return encodeArray(raw)
+}
+
+// writtenId gives the id the character id refers to is written under,
+// leaving night events and ids not on the script alone.
+func writtenId(id string, ids map[string]string) string {
+	if written, found := ids[CanonicalId(id)]; found {
+		return written
+	}
+	return id
+}
+
+func writtenIds(list []string, ids map[string]string) []string {
+	out := make([]string, len(list))
+	for i, id := range list {
+		out[i] = writtenId(id, ids)
+	}
+	return out
+}
+
+// sameAs reports whether r is a copy of o that a bare id loses nothing of.
+// Art, edition, flavour and night order positions may be left out, since the
+// tool fills them in, but any that are given must match.
+func (r *Role) sameAs(o *Role) bool {
+	return r.Name == o.Name &&
+		(len(r.ImageUrls) == 0 || slices.Equal(r.ImageUrls, o.ImageUrls)) &&
+		(r.Edition == "" || r.Edition == o.Edition) &&
+		(r.Flavour == "" || r.Flavour == o.Flavour) &&
+		(r.FirstNightOrder == -1 || r.FirstNightOrder == o.FirstNightOrder) &&
+		(r.OtherNightOrder == -1 || r.OtherNightOrder == o.OtherNightOrder) &&
+		r.Team == o.Team &&
+		r.Ability == o.Ability &&
+		r.FirstNightReminder == o.FirstNightReminder &&
+		r.OtherNightReminder == o.OtherNightReminder &&
+		slices.Equal(r.ReminderTokens, o.ReminderTokens) &&
+		slices.Equal(r.GlobalReminders, o.GlobalReminders) &&
+		r.AltersSetup == o.AltersSetup &&
+		(len(r.Jinxes) == 0 || slices.Equal(r.Jinxes, o.Jinxes)) &&
+		(len(r.Special) == 0 || sameSpecials(r.Special, o.Special)) &&
+		len(r.Extra) == 0
+}
+
+// sameSpecials compares specials regardless of the order their members
+// were written in.
+func sameSpecials(a, b []Special) bool {
+	return slices.EqualFunc(a, b, func(x, y Special) bool {
+		x.order, y.order = nil, nil
+		return reflect.DeepEqual(x, y)
+	})
+}
+
+type compactRole struct {
+	*Role
+	ids map[string]string
+}
+
+func (c compactRole) MarshalJSON() ([]byte, error) {
+	r := c.Role
+	e := newObjectEncoder()
+	e.field("id", r.sourceId())
+	e.field("name", r.Name)
+	if len(r.ImageUrls) == 1 {
+		e.field("image", r.ImageUrls[0])
+	} else if len(r.ImageUrls) > 1 {
+		e.field("image", r.ImageUrls)
+	}
+	e.field("team", r.Team)
+	if r.Edition != "" {
+		e.field("edition", r.Edition)
+	}
+	e.field("ability", r.Ability)
+	if r.Flavour != "" {
+		e.field("flavor", r.Flavour)
+	}
+	if r.FirstNightOrder != -1 {
+		e.field("firstNight", r.FirstNightOrder)
+	}
+	if r.FirstNightReminder != "" {
+		e.field("firstNightReminder", r.FirstNightReminder)
+	}
+	if r.OtherNightOrder != -1 {
+		e.field("otherNight", r.OtherNightOrder)
+	}
+	if r.OtherNightReminder != "" {
+		e.field("otherNightReminder", r.OtherNightReminder)
+	}
+	if len(r.ReminderTokens) > 0 {
+		e.field("reminders", r.ReminderTokens)
+	}
+	if len(r.GlobalReminders) > 0 {
+		e.field("remindersGlobal", r.GlobalReminders)
+	}
+	if r.AltersSetup {
+		e.field("setup", true)
+	}
+	if len(r.Jinxes) > 0 {
+		jinxes := make(Jinxes, len(r.Jinxes))
+		for i, j := range r.Jinxes {
+			jinxes[i] = Jinx{Id: writtenId(j.Id, c.ids), Reason: j.Reason}
+		}
+		e.field("jinxes", jinxes)
+	}
+	if len(r.Special) > 0 {
+		e.field("special", r.Special)
+	}
+	e.extra(r.Extra, roleKeys)
+	return e.bytes()
+}
+
+type compactMeta struct {
+	*ScriptMeta
+	ids map[string]string
+}
+
+func (c compactMeta) empty() bool {
+	m := c.ScriptMeta
+	return m.Name == "" && m.Author == "" && m.Logo == "" && m.Background == "" &&
+		m.Almanac == "" && !m.HideTitle && len(m.Bootlegger) == 0 &&
+		len(m.FirstNight) == 0 && len(m.OtherNight) == 0 && len(m.Extra) == 0
+}
+
+func (c compactMeta) MarshalJSON() ([]byte, error) {
+	m := c.ScriptMeta
+	e := newObjectEncoder()
+	e.field("id", "_meta")
+	strs := []struct {
+		key   string
+		value string
+	}{
+		{"name", m.Name},
+		{"author", m.Author},
+		{"logo", m.Logo},
+		{"background", m.Background},
+		{"almanac", m.Almanac},
+	}
+	for _, s := range strs {
+		if s.value != "" {
+			e.field(s.key, s.value)
+		}
+	}
+	if m.HideTitle {
+		e.field("hideTitle", true)
+	}
+	if len(m.Bootlegger) > 0 {
+		e.field("bootlegger", m.Bootlegger)
+	}
+	if len(m.FirstNight) > 0 {
+		e.field("firstNight", writtenIds(m.FirstNight, c.ids))
+	}
+	if len(m.OtherNight) > 0 {
+		e.field("otherNight", writtenIds(m.OtherNight, c.ids))
+	}
+	e.extra(m.Extra, metaKeys)
+	return e.bytes()
+}
@@ -0,0 +1,207 @@
+package botc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompactToolUrl(t *testing.T) {
+	for _, f := range scriptAssets(t) {
+		t.Run(f, func(t *testing.T) {
+			s := loadScript(t, f)
+			full, err := s.OfficialToolUrl()
+			if err != nil {
+				t.Fatal(err)
+			}
+			link, err := s.CompactToolUrl()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if link.Length > len(full) {
+				t.Errorf("compact link is %d long, the full one %d", link.Length, len(full))
+			}
+			back, err := ParseShareUrl(link.Url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			back.PopulateOfficialIndex()
+			if !reflect.DeepEqual(back.Ids(), s.Ids()) {
+				t.Errorf("compact link loads %v, want %v", back.Ids(), s.Ids())
+			}
+		})
+	}
+}
+
+func TestMarshalCompactJSON(t *testing.T) {
+	washerwoman := func(extra string) string {
+		return `{"id":"washerwoman","name":"Washerwoman",` + extra + `"team":"townsfolk",` +
+			`"firstNightReminder":"Show the Townsfolk character token. Point to both the *TOWNSFOLK* and *WRONG* players.",` +
+			`"reminders":["Townsfolk","Wrong"],"ability":"You start knowing that 1 of 2 players is a particular Townsfolk."}`
+	}
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "copy",
+			doc:  "[" + washerwoman(`"edition":"tb","firstNight":51,`) + "]",
+			want: `["washerwoman"]`,
+		},
+		{
+			name: "custom art",
+			doc:  "[" + washerwoman(`"image":"https://example.com/w.png",`) + "]",
+			want: `[{"id":"washerwoman","name":"Washerwoman","image":"https://example.com/w.png","team":"townsfolk","ability":"You start knowing that 1 of 2 players is a particular Townsfolk.","firstNightReminder":"Show the Townsfolk character token. Point to both the *TOWNSFOLK* and *WRONG* players.","reminders":["Townsfolk","Wrong"]}]`,
+		},
+		{
+			name: "custom night order",
+			doc:  "[" + washerwoman(`"firstNight":1,`) + "]",
+			want: `[{"id":"washerwoman","name":"Washerwoman","team":"townsfolk","ability":"You start knowing that 1 of 2 players is a particular Townsfolk.","firstNight":1,"firstNightReminder":"Show the Townsfolk character token. Point to both the *TOWNSFOLK* and *WRONG* players.","reminders":["Townsfolk","Wrong"]}]`,
+		},
+		{
+			name: "mixed case ids",
+			doc:  `[{"id":"_meta","firstNight":["dusk","my_char","My_Char"]},{"id":"My_Char","name":"Mine","team":"demon","ability":"y","firstNight":3,"jinxes":[{"id":"Other","reason":"r"}]},{"id":"other","name":"O","team":"minion","ability":"z"}]`,
+			want: `[{"id":"_meta","firstNight":["dusk","My_Char","My_Char"]},{"id":"My_Char","name":"Mine","team":"demon","ability":"y","firstNight":3,"jinxes":[{"id":"other","reason":"r"}]},{"id":"other","name":"O","team":"minion","ability":"z"}]`,
+		},
+		{
+			name: "html",
+			doc:  `[{"id":"_meta","name":"Cats & <Dogs>"},{"id":"x","name":"X","team":"minion","ability":"a & b","jinxes":[{"id":"y","reason":"c & d"}]}]`,
+			want: `[{"id":"_meta","name":"Cats & <Dogs>"},{"id":"x","name":"X","team":"minion","ability":"a & b","jinxes":[{"id":"y","reason":"c & d"}]}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Script
+			if err := s.UnmarshalJSON([]byte(tt.doc)); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.MarshalCompactJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalCompactJSONSmaller(t *testing.T) {
+	data, err := os.ReadFile("asset/Sects & Violets.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := loadScript(t, "asset/Sects & Violets.json")
+	got, err := s.MarshalCompactJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) > len(data) || bytes.Contains(got, []byte(`\u0026`)) {
+		t.Errorf("compact document is %d long, the asset %d:\n%s", len(got), len(data), got)
+	}
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseShareUrl(t *testing.T) {
+	doc := []byte(`[{"id":"_meta","name":"Tiny"},"washerwoman","imp"]`)
+	link, err := shareUrl(doc, gzip.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := u.Query().Get(shareParam)
+	urlSafe := strings.NewReplacer("+", "-", "/", "_").Replace(strings.TrimRight(payload, "="))
+	for _, s := range []string{
+		link,
+		"  " + link + "\n",
+		payload,
+		url.QueryEscape(payload),
+		strings.ReplaceAll(payload, "+", " "),
+		urlSafe,
+		officialToolBase + "?" + shareParam + "=" + urlSafe,
+	} {
+		script, err := ParseShareUrl(s)
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+			continue
+		}
+		if script.Meta.Name != "Tiny" || !slices.Equal(script.OriginalCharacterIds, []string{"washerwoman", "imp"}) {
+			t.Errorf("%q: loaded %+v", s, script)
+		}
+	}
+}
+
+func TestParseShareUrlInvalid(t *testing.T) {
+	compressed := gzipped(t, []byte(`["washerwoman","imp"]`))
+	encode := func(b []byte) string {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	corrupt := slices.Clone(compressed)
+	corrupt[len(corrupt)-5] ^= 0xff
+
+	tests := []struct {
+		name   string
+		url    string
+		reason string
+		cause  error
+	}{
+		{"other site", "https://example.com/", "no script parameter", nil},
+		{"no script parameter", officialToolBase + "?name=x", "no script parameter", nil},
+		{"empty script parameter", officialToolBase + "?script=", "no script parameter", nil},
+		{"malformed url", "https://[::1/?script=x", "not a url", nil},
+		{"bad escape", "H4sI%zz", "bad escape", nil},
+		{"not base64", "not*base64!", "bad base64", nil},
+		{"base64 cut mid quantum", encode(compressed)[:5], "bad base64", nil},
+		{"empty", "", "bad gzip", io.EOF},
+		{"not gzip", encode([]byte("plain text, not compressed")), "bad gzip", gzip.ErrHeader},
+		{"truncated", encode(compressed[:len(compressed)/2]), "bad gzip", io.ErrUnexpectedEOF},
+		{"bad checksum", encode(corrupt), "bad gzip", gzip.ErrChecksum},
+		{"too large", encode(gzipped(t, make([]byte, maxSharedScript+1))), "script too large", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseShareUrl(tt.url)
+			var shareErr *ShareUrlError
+			if !errors.As(err, &shareErr) {
+				t.Fatalf("got %v, want a share url error", err)
+			}
+			if shareErr.reason != tt.reason {
+				t.Errorf("got %v, want %q", err, tt.reason)
+			}
+			if tt.cause != nil && !errors.Is(err, tt.cause) {
+				t.Errorf("got %v, want it to wrap %v", err, tt.cause)
+			}
+		})
+	}
+
+	// a payload that decodes but isn't a script fails as a script
+	_, err := ParseShareUrl(encode(gzipped(t, []byte(`{"id":"_meta"}`))))
+	var shareErr *ShareUrlError
+	if err == nil || errors.As(err, &shareErr) {
+		t.Errorf("got %v, want a script decoding error", err)
+	}
+}
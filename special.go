@@ -1,9 +1,7 @@
 package botc
 
 import (
-	"reflect"
 	"slices"
-	"strings"
 )
 
 var specialTypeEnum []string = []string{
@@ -49,12 +47,29 @@ var specialGlobalEnum []string = []string{
 	"dead",
 }
 
+var specialKeys = []string{
+	"type",
+	"name",
+	"value",
+	"time",
+	"global",
+}
+
+var specialEnums = map[string][]string{
+	"type":   specialTypeEnum,
+	"name":   specialNameEnum,
+	"time":   specialTimeEnum,
+	"global": specialGlobalEnum,
+}
+
 type Special struct {
-	Type   string `json:"type,omitempty"`
-	Name   string `json:"name,omitempty"`
-	Time   string `json:"time,omitempty"`
-	Global string `json:"global,omitempty"`
-	Value  any    `json:"value,omitempty"`
+	Type   string         `json:"type,omitempty"`
+	Name   string         `json:"name,omitempty"`
+	Time   string         `json:"time,omitempty"`
+	Global string         `json:"global,omitempty"`
+	Value  any            `json:"value,omitempty"`
+	Extra  map[string]any `json:"-"`
+	order  []string
 }
 
 func (s *Special) StringValue() (string, bool) {
@@ -75,20 +90,55 @@ func (s *Special) NumValue() (int, bool) {
 	}
 }
 
+func (s *Special) setExtra(k string, v any) {
+	if s.Extra == nil {
+		s.Extra = make(map[string]any)
+	}
+	s.Extra[k] = v
+}
+
+func (s Special) MarshalJSON() ([]byte, error) {
+	e := newObjectEncoder()
+	e.fields(s.order, s.encodeAll)
+	return e.bytes()
+}
+
+func (s *Special) encodeAll(emit func(k string, v any)) {
+	s.encodeFields(emit)
+	for _, k := range extraKeys(s.Extra, specialKeys) {
+		emit(k, s.Extra[k])
+	}
+}
+
 func (s *Special) ToMap() map[string]any {
 	m := make(map[string]any)
-	rt := reflect.TypeOf(s)
-	rv := reflect.ValueOf(s)
-	for i := 0; i < rt.NumField(); i++ {
-		f := rt.Field(i)
-		t := f.Tag.Get("json")
-		n := strings.Split(t, ",")[0]
-		v := rv.Field(i)
-		m[n] = v.Interface()
+	for k, v := range s.Extra {
+		m[k] = v
 	}
+	s.encodeFields(func(k string, v any) {
+		m[k] = v
+	})
 	return m
 }
 
+func (s *Special) encodeFields(emit func(k string, v any)) {
+	if s.Type != "" {
+		emit("type", s.Type)
+	}
+	if s.Name != "" {
+		emit("name", s.Name)
+	}
+	if s.Value != nil {
+		emit("value", s.Value)
+	}
+	if s.Time != "" {
+		emit("time", s.Time)
+	}
+	if s.Global != "" {
+		emit("global", s.Global)
+	}
+}
+
 func extractSpecial(m map[string]any) ([]Special, error) {
 	raw, ok, err := extractSlice("special", m)
 	if !ok {
@@ -102,26 +152,38 @@ func extractSpecial(m map[string]any) ([]Special, error) {
 	for _, item := range raw {
 		var special Special
 
-		for k, v := range item.(map[string]any) {
+		sm, ok := item.(map[string]any)
+		if !ok {
+			return specials, NewConversionError("special", item)
+		}
+		for k, v := range sm {
+			enum, known := specialEnums[k]
+			if k == "value" {
+				special.Value = v
+				continue
+			}
+			if !known {
+				special.setExtra(k, v)
+				continue
+			}
+			val, ok := v.(string)
+			if !ok {
+				return specials, NewConversionError(k, v)
+			}
+			if !slices.Contains(enum, val) {
+				// keep values this package doesn't know yet so they survive re-encoding
+				special.setExtra(k, v)
+				continue
+			}
 			switch k {
 			case "type":
-				if slices.Contains(specialTypeEnum, v.(string)) {
-					special.Type = v.(string)
-				}
+				special.Type = val
 			case "name":
-				if slices.Contains(specialNameEnum, v.(string)) {
-					special.Name = v.(string)
-				}
+				special.Name = val
 			case "time":
-				if slices.Contains(specialTimeEnum, v.(string)) {
-					special.Time = v.(string)
-				}
+				special.Time = val
 			case "global":
-				if slices.Contains(specialGlobalEnum, v.(string)) {
-					special.Global = v.(string)
-				}
-			case "value":
-				special.Value = v
+				special.Global = val
 			}
 		}
 
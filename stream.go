@@ -0,0 +1,395 @@
+package botc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type jinxDocument struct {
+	Id     *string `json:"id"`
+	Reason *string `json:"reason"`
+}
+
+// roleDocument is the typed shape of a character item. Decoding into it
+// avoids building a map[string]any tree for every role; anything it cannot
+// represent falls back to decodeRole so the errors reported are the same.
+type roleDocument struct {
+	Id                 *string            `json:"id"`
+	Name               *string            `json:"name"`
+	Edition            *string            `json:"edition"`
+	Image              *imageDocument     `json:"image"`
+	Team               *string            `json:"team"`
+	Ability            *string            `json:"ability"`
+	FirstNight         *float64           `json:"firstNight"`
+	FirstNightReminder *string            `json:"firstNightReminder"`
+	OtherNight         *float64           `json:"otherNight"`
+	OtherNightReminder *string            `json:"otherNightReminder"`
+	RemindersGlobal    *[]string          `json:"remindersGlobal"`
+	Reminders          *[]string          `json:"reminders"`
+	Setup              *bool              `json:"setup"`
+	Flavor             *string            `json:"flavor"`
+	Special            *[]specialDocument `json:"special"`
+	Jinxes             *[]jinxDocument    `json:"jinxes"`
+}
+
+// imageDocument holds a character's image, which may be a single url or a
+// list of them.
+type imageDocument struct {
+	urls   []string
+	single bool
+}
+
+func (d *imageDocument) UnmarshalJSON(raw []byte) error {
+	if raw[0] == '"' {
+		d.single = true
+		d.urls = make([]string, 1)
+		return json.Unmarshal(raw, &d.urls[0])
+	}
+	return json.Unmarshal(raw, &d.urls)
+}
+
+// specialDocument holds a special as extractSpecial expects it, along with
+// the order of its members.
+type specialDocument struct {
+	members map[string]any
+	order   []string
+}
+
+func (d *specialDocument) UnmarshalJSON(raw []byte) error {
+	d.order, _ = scanObject(raw, specialKeys)
+	return json.Unmarshal(raw, &d.members)
+}
+
+func decodeRoleDocument(raw []byte) (Role, bool) {
+	keys, null := scanObject(raw, roleKeys)
+	// null is only sometimes acceptable, leave it to decodeRole to decide
+	if null {
+		return Role{}, false
+	}
+	// json.Unmarshal matches keys without regard to case, so only a document
+	// whose keys are exactly the known ones, each given once, can use it;
+	// decodeRole keeps or reports anything else
+	for i, k := range keys {
+		if !slices.Contains(roleKeys, k) || slices.Contains(keys[:i], k) {
+			return Role{}, false
+		}
+	}
+	var doc roleDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return Role{}, false
+	}
+	return doc.toRole(keys)
+}
+
+// scanObject lists the member names of the JSON object in raw, sharing the
+// strings in known rather than copying them, and reports whether a null
+// literal appears outside of any string, so "annulled" in an ability does
+// not count.
+func scanObject(raw []byte, known []string) ([]string, bool) {
+	keys := make([]string, 0, len(known))
+	depth := 0
+	expectKey := false
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; c {
+		case '"':
+			end := stringEnd(raw, i+1)
+			if expectKey {
+				keys = append(keys, memberName(raw[i:end+1], known))
+				expectKey = false
+			}
+			i = end
+		case '{', '[':
+			depth++
+			expectKey = c == '{' && depth == 1
+		case '}', ']':
+			depth--
+		case ',':
+			expectKey = depth == 1
+		case 'n':
+			if bytes.HasPrefix(raw[i:], []byte("null")) {
+				return keys, true
+			}
+		}
+	}
+	return keys, false
+}
+
+// stringEnd finds the quote closing the string whose contents start at i.
+func stringEnd(raw []byte, i int) int {
+	for {
+		n := bytes.IndexByte(raw[i:], '"')
+		if n == -1 {
+			return len(raw) - 1
+		}
+		i += n
+		// the quote is escaped when an odd number of backslashes precede it
+		escapes := 0
+		for j := i - 1; j >= 0 && raw[j] == '\\'; j-- {
+			escapes++
+		}
+		if escapes%2 == 0 {
+			return i
+		}
+		i++
+	}
+}
+
+// memberName unquotes a member name, which only needs decoding when it
+// holds an escape.
+func memberName(quoted []byte, known []string) string {
+	if bytes.IndexByte(quoted, '\\') == -1 {
+		name := quoted[1 : len(quoted)-1]
+		for _, k := range known {
+			if string(name) == k {
+				return k
+			}
+		}
+		return string(name)
+	}
+	var name string
+	_ = json.Unmarshal(quoted, &name)
+	return name
+}
+
+func (d *roleDocument) toRole(order []string) (Role, bool) {
+	var r Role
+	if d.Id == nil || d.Name == nil || d.Team == nil || d.Ability == nil {
+		return r, false
+	}
+	source := &roleSource{
+		order: order,
+		id:    *d.Id,
+		name:  *d.Name,
+	}
+	source.mark("id")
+	source.mark("name")
+	source.mark("team")
+	source.mark("ability")
+
+	r.Id = CanonicalId(*d.Id)
+	r.Name = strings.TrimSuffix(*d.Name, " RAH")
+	r.Team = RoleType(*d.Team)
+	if !slices.Contains(RoleTypeOrder, r.Team) {
+		return r, false
+	}
+	r.Ability = *d.Ability
+
+	if d.Edition != nil {
+		source.mark("edition")
+		r.Edition = Edition(*d.Edition)
+	}
+
+	r.ImageUrls = []string{}
+	if d.Image != nil {
+		source.mark("image")
+		r.ImageUrls = d.Image.urls
+		if d.Image.single {
+			source.imageString = true
+			source.image = d.Image.urls[0]
+		}
+		for _, u := range r.ImageUrls {
+			if _, err := url.Parse(u); err != nil {
+				return r, false
+			}
+		}
+	}
+
+	r.FirstNightOrder = -1
+	if d.FirstNight != nil {
+		source.mark("firstNight")
+		r.FirstNightOrder = *d.FirstNight
+	}
+	if d.FirstNightReminder != nil {
+		source.mark("firstNightReminder")
+		r.FirstNightReminder = *d.FirstNightReminder
+	}
+	r.OtherNightOrder = -1
+	if d.OtherNight != nil {
+		source.mark("otherNight")
+		r.OtherNightOrder = *d.OtherNight
+	}
+	if d.OtherNightReminder != nil {
+		source.mark("otherNightReminder")
+		r.OtherNightReminder = *d.OtherNightReminder
+	}
+
+	r.GlobalReminders = []string{}
+	if d.RemindersGlobal != nil {
+		source.mark("remindersGlobal")
+		r.GlobalReminders = *d.RemindersGlobal
+	}
+	r.ReminderTokens = []string{}
+	if d.Reminders != nil {
+		source.mark("reminders")
+		r.ReminderTokens = *d.Reminders
+	}
+	if d.Setup != nil {
+		source.mark("setup")
+		r.AltersSetup = *d.Setup
+	}
+	if d.Flavor != nil {
+		source.mark("flavor")
+		r.Flavour = *d.Flavor
+	}
+
+	r.Special = []Special{}
+	if d.Special != nil {
+		source.mark("special")
+		specials := make([]any, len(*d.Special))
+		for i, s := range *d.Special {
+			specials[i] = s.members
+		}
+		sp, err := extractSpecial(map[string]any{"special": specials})
+		if err != nil {
+			return r, false
+		}
+		for i := range sp {
+			sp[i].order = (*d.Special)[i].order
+		}
+		r.Special = sp
+	}
+
+	if d.Jinxes != nil {
+		source.mark("jinxes")
+		r.Jinxes = make(Jinxes, 0, len(*d.Jinxes))
+		for _, j := range *d.Jinxes {
+			if j.Id == nil || j.Reason == nil {
+				return r, false
+			}
+			r.Jinxes = append(r.Jinxes, Jinx{Id: *j.Id, Reason: *j.Reason})
+		}
+	}
+
+	r.source = source
+	return r, true
+}
+
+// RosterDecoder reads a roster one item at a time, so a large collection
+// never has to be held in memory as a whole.
+type RosterDecoder struct {
+	dec     *json.Decoder
+	meta    ScriptMeta
+	index   int
+	started bool
+	done    bool
+}
+
+func NewRosterDecoder(r io.Reader) *RosterDecoder {
+	return &RosterDecoder{
+		dec: json.NewDecoder(r),
+	}
+}
+
+func (d *RosterDecoder) Meta() ScriptMeta {
+	return d.meta
+}
+
+// Next returns the next character in the roster, reading only as much input
+// as it needs. It returns io.EOF once the roster is exhausted. A
+// *DecodeErrors describes a single bad item, and decoding can carry on with
+// the next call.
+func (d *RosterDecoder) Next() (*Role, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	if !d.started {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return nil, NewConversionError("roster", tok)
+		}
+		d.started = true
+	}
+	for d.dec.More() {
+		item := rosterItem{ptr: pointerJoin("", strconv.Itoa(d.index))}
+		if err := d.dec.Decode(&item); err != nil {
+			return nil, err
+		}
+		d.index++
+		if item.meta != nil {
+			d.meta = *item.meta
+		}
+		if item.err != nil {
+			return nil, item.err
+		}
+		if item.role != nil {
+			return item.role, nil
+		}
+	}
+	_, err := d.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	d.done = true
+	return nil, io.EOF
+}
+
+// rosterItem decodes a single roster item straight from the bytes the
+// decoder lends it, which saves copying every item before decoding it.
+type rosterItem struct {
+	ptr  string
+	role *Role
+	meta *ScriptMeta
+	err  error
+}
+
+func (it *rosterItem) UnmarshalJSON(raw []byte) error {
+	switch raw[0] {
+	case '"':
+		return nil
+	case '{':
+	default:
+		var v any
+		_ = json.Unmarshal(raw, &v)
+		it.err = NewDecodeErrors([]*DecodeError{NewDecodeError("", it.ptr, NewConversionError("item", v))})
+		return nil
+	}
+
+	if role, ok := decodeRoleDocument(raw); ok {
+		it.role = &role
+		return nil
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return err
+	}
+	if m["id"] == "_meta" {
+		meta, metaErrs := decodeScriptMeta(m)
+		meta.order, _ = scanObject(raw, metaKeys)
+		it.meta = &meta
+		if len(metaErrs) > 0 {
+			it.err = newItemDecodeErrors("_meta", it.ptr, metaErrs)
+		}
+		return nil
+	}
+	if isOfficialReference(m) {
+		if _, err := extractRequiredString("id", m); err != nil {
+			it.err = NewDecodeErrors([]*DecodeError{NewDecodeError("", pointerJoin(it.ptr, "id"), err)})
+		}
+		return nil
+	}
+	role, roleErrs := decodeRole(m)
+	if len(roleErrs) > 0 {
+		it.err = newItemDecodeErrors(role.Id, it.ptr, roleErrs)
+		return nil
+	}
+	role.keepOrder(raw)
+	it.role = &role
+	return nil
+}
+
+func newItemDecodeErrors(id string, ptr string, errs []*fieldError) *DecodeErrors {
+	decodeErrs := make([]*DecodeError, len(errs))
+	for i, e := range errs {
+		decodeErrs[i] = NewDecodeError(id, pointerJoin(ptr, e.key), e.err)
+	}
+	return NewDecodeErrors(decodeErrs)
+}
@@ -0,0 +1,143 @@
+package botc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestScanObject(t *testing.T) {
+	tests := []struct {
+		raw  string
+		keys []string
+		null bool
+	}{
+		{`{"ability":"The vote is annulled."}`, []string{"ability"}, false},
+		{`{"ability":"null"}`, []string{"ability"}, false},
+		{`{"ability":"say \"null\": now"}`, []string{"ability"}, false},
+		{`{"id":"x","jinxes":[{"id":"y","reason":"z"}]}`, []string{"id", "jinxes"}, false},
+		{`{ "NAME" : "x", "na\u006de": "y" }`, []string{"NAME", "name"}, false},
+		{`{"flavor":null}`, []string{"flavor"}, true},
+		{`{"reminders":["a",null]}`, []string{"reminders"}, true},
+		{`{"ability":"a\\","flavor":null}`, []string{"ability", "flavor"}, true},
+	}
+	for _, tt := range tests {
+		keys, null := scanObject([]byte(tt.raw), roleKeys)
+		if !slices.Equal(keys, tt.keys) || null != tt.null {
+			t.Errorf("scanObject(%s) = %q, %v, want %q, %v", tt.raw, keys, null, tt.keys, tt.null)
+		}
+	}
+	raw := []byte(`{"id":"x","name":"X","team":"townsfolk","ability":"Your vote is annulled."}`)
+	if _, ok := decodeRoleDocument(raw); !ok {
+		t.Error("a role mentioning null in its text left the typed path")
+	}
+	for _, raw := range []string{
+		`{"id":"x","name":"X","team":"townsfolk","ability":"y","colour":"red"}`,
+		`{"id":"x","name":"X","team":"townsfolk","ability":"y","name":"Z"}`,
+		`{"id":"x","NAME":"X","team":"townsfolk","ability":"y"}`,
+	} {
+		if _, ok := decodeRoleDocument([]byte(raw)); ok {
+			t.Errorf("%s took the typed path", raw)
+		}
+	}
+}
+
+func TestRosterDecoderKeyCase(t *testing.T) {
+	doc := `[{"id":"a","NAME":"A","team":"townsfolk","ability":"y"}]`
+	var r Roster
+	want := r.UnmarshalJSON([]byte(doc))
+	if want == nil {
+		t.Fatal("Roster accepted a wrongly cased key")
+	}
+	_, err := NewRosterDecoder(strings.NewReader(doc)).Next()
+	var decodeErrs *DecodeErrors
+	if !errors.As(err, &decodeErrs) {
+		t.Fatalf("got %v, want decode errors", err)
+	}
+	if errs := decodeErrs.Errors(); len(errs) != 1 || errs[0].Path() != "/0/name" || !strings.Contains(err.Error(), want.Error()) {
+		t.Errorf("got %v, want %v at /0/name", err, want)
+	}
+}
+
+func TestRosterDecoderMatchesRoster(t *testing.T) {
+	data, err := os.ReadFile("asset/Released_Homebrew.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := loadRoster(t, "asset/Released_Homebrew.json")
+	d := NewRosterDecoder(bytes.NewReader(data))
+	n := 0
+	for {
+		r, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n >= len(want.Characters) {
+			t.Fatalf("streamed more than %d roles", len(want.Characters))
+		}
+		got, _ := json.Marshal(r)
+		exp, _ := json.Marshal(want.Characters[n])
+		if string(got) != string(exp) {
+			t.Errorf("role %d:\n got %s\nwant %s", n, got, exp)
+		}
+		n++
+	}
+	if n != len(want.Characters) {
+		t.Errorf("streamed %d roles, want %d", n, len(want.Characters))
+	}
+}
+
+func BenchmarkRosterDecoder(b *testing.B) {
+	data, err := os.ReadFile("asset/Released_Homebrew.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		d := NewRosterDecoder(bytes.NewReader(data))
+		for {
+			_, err := d.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkNewRole decodes the same roster through map[string]any and
+// NewRole, the path every load took before RosterDecoder.
+func BenchmarkNewRole(b *testing.B) {
+	data, err := os.ReadFile("asset/Released_Homebrew.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		var items []any
+		if err := json.Unmarshal(data, &items); err != nil {
+			b.Fatal(err)
+		}
+		for _, item := range items {
+			m, ok := item.(map[string]any)
+			if !ok || m["id"] == "_meta" {
+				continue
+			}
+			if _, err := NewRole(m); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}